@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/chetanugale/scheduling-system/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// IdentityKey is the gin context key holding the authenticated caller.
+const IdentityKey = "identity"
+
+// RoleAdmin grants access to every event regardless of organizer lists.
+const RoleAdmin = "admin"
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+	ErrNoOrgBinding       = errors.New("credentials are not bound to an organization")
+)
+
+// Identity is the caller resolved from a JWT or an API key.
+type Identity struct {
+	UserID string   `json:"userId"`
+	Name   string   `json:"name,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
+	OrgID  string   `json:"orgId,omitempty"` // empty for admins picking an org per request
+	Method string   `json:"method"`
+}
+
+func (id Identity) HasRole(role string) bool {
+	for _, r := range id.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Claims are the JWT claims accepted by the Authenticator.
+type Claims struct {
+	Name  string   `json:"name,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+	Org   string   `json:"org,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Authenticator verifies HS256 signed JWTs and static API keys.
+type Authenticator struct {
+	secret  []byte
+	apiKeys map[string]Identity
+}
+
+func NewAuthenticator(secret string, apiKeys map[string]Identity) *Authenticator {
+	return &Authenticator{secret: []byte(secret), apiKeys: apiKeys}
+}
+
+// ParseAPIKeys parses "key:user,key2:user2:admin:org" into a key -> Identity map.
+// An optional third field is a "|" separated list of roles and an optional
+// fourth field binds the key to an organization.
+func ParseAPIKeys(raw string) map[string]Identity {
+	keys := map[string]Identity{}
+	for _, entry := range strings.Split(raw, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			continue
+		}
+		id := Identity{UserID: parts[1], Method: "apikey"}
+		if len(parts) > 2 && parts[2] != "" {
+			id.Roles = strings.Split(parts[2], "|")
+		}
+		if len(parts) > 3 {
+			id.OrgID = parts[3]
+		}
+		keys[parts[0]] = id
+	}
+	return keys
+}
+
+// Authenticate resolves the caller from the Authorization or X-API-Key header.
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, error) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return a.verifyAPIKey(key)
+	}
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return Identity{}, ErrMissingCredentials
+	}
+	return a.verifyToken(token)
+}
+
+func (a *Authenticator) verifyAPIKey(key string) (Identity, error) {
+	id, ok := a.apiKeys[key]
+	if !ok {
+		return Identity{}, ErrInvalidAPIKey
+	}
+	return id, nil
+}
+
+func (a *Authenticator) verifyToken(raw string) (Identity, error) {
+	if len(a.secret) == 0 {
+		return Identity{}, ErrInvalidToken
+	}
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		return a.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || claims.Subject == "" {
+		return Identity{}, ErrInvalidToken
+	}
+	return Identity{UserID: claims.Subject, Name: claims.Name, Roles: claims.Roles, OrgID: claims.Org, Method: "jwt"}, nil
+}
+
+// SignToken issues an HS256 token for the given claims.
+func (a *Authenticator) SignToken(claims Claims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+}
+
+// Middleware rejects unauthenticated requests and stores the Identity and its
+// organization on the context. The org bound to the token or key always wins
+// over the X-Org-ID header, which only admins may use. Anybody else without an
+// org could otherwise reach every tenant.
+func Middleware(a *Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := a.Authenticate(c.Request)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, bson.M{"error": err.Error()})
+			return
+		}
+		org := id.OrgID
+		if org == "" {
+			if !id.HasRole(RoleAdmin) {
+				c.AbortWithStatusJSON(http.StatusForbidden, bson.M{"error": ErrNoOrgBinding.Error()})
+				return
+			}
+			org = c.GetHeader(tenant.Header)
+		}
+		if org == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, bson.M{"error": tenant.ErrNoOrg.Error()})
+			return
+		}
+		SetIdentity(c, id)
+		tenant.Set(c, org)
+		c.Next()
+	}
+}
+
+type identityCtxKey struct{}
+
+func SetIdentity(c *gin.Context, id Identity) {
+	c.Set(IdentityKey, id)
+}
+
+// WithIdentity attaches id to ctx for callers that are not authenticated by
+// Middleware, such as guests answering a shared poll.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityCtxKey{}, id)
+}
+
+// FromContext returns the Identity stored by Middleware or WithIdentity. ctx is
+// usually the *gin.Context passed down from a handler.
+func FromContext(ctx context.Context) (Identity, bool) {
+	if id, ok := ctx.Value(identityCtxKey{}).(Identity); ok {
+		return id, true
+	}
+	id, ok := ctx.Value(IdentityKey).(Identity)
+	return id, ok
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRouter(a *Authenticator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(a))
+	router.GET("/whoami", func(c *gin.Context) {
+		id, _ := FromContext(c)
+		org, _ := tenant.FromContext(c)
+		c.JSON(http.StatusOK, gin.H{"userId": id.UserID, "roles": id.Roles, "org": org})
+	})
+	return router
+}
+
+func TestMiddlewareJWT(t *testing.T) {
+	a := NewAuthenticator("secret", nil)
+	router := newTestRouter(a)
+
+	token, err := a.SignToken(Claims{Name: "Alice", Org: "eng", RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "alice",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}})
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodGet, "/whoami", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Org-ID", "sales") // ignored, the token is bound to eng
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"userId":"alice"`)
+	assert.Contains(t, resp.Body.String(), `"org":"eng"`)
+}
+
+func TestMiddlewareRejectsBadToken(t *testing.T) {
+	signer := NewAuthenticator("other-secret", nil)
+	router := newTestRouter(NewAuthenticator("secret", nil))
+
+	expired, _ := NewAuthenticator("secret", nil).SignToken(Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "alice",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+	}})
+	forged, _ := signer.SignToken(Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "alice",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}})
+
+	for _, header := range []string{"", "Bearer " + expired, "Bearer " + forged, "Basic abc"} {
+		req, _ := http.NewRequest(http.MethodGet, "/whoami", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code, header)
+	}
+}
+
+func TestMiddlewareAPIKey(t *testing.T) {
+	router := newTestRouter(NewAuthenticator("", ParseAPIKeys("k1:svc-bot, bad, k2:, k3:root:admin")))
+
+	req, _ := http.NewRequest(http.MethodGet, "/whoami", nil)
+	req.Header.Set("X-API-Key", "k1")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code) // no org bound to the key
+
+	req.Header.Set("X-Org-ID", "eng")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code) // only admins may pick an org
+
+	req.Header.Set("X-API-Key", "k2")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	req.Header.Set("X-API-Key", "k3")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"roles":["admin"]`)
+	assert.Contains(t, resp.Body.String(), `"org":"eng"`)
+
+	req.Header.Del("X-Org-ID")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code) // admin without an org header
+}
@@ -2,66 +2,256 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // TZID lookups in imported calendars, the alpine image has no zoneinfo
 
-	"github.com/gin-gonic/gin"
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/config"
 	"github.com/chetanugale/scheduling-system/constants"
 	"github.com/chetanugale/scheduling-system/handlers"
+	"github.com/chetanugale/scheduling-system/health"
+	"github.com/chetanugale/scheduling-system/logging"
+	"github.com/chetanugale/scheduling-system/metrics"
 	"github.com/chetanugale/scheduling-system/models"
+	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 
 	"github.com/chetanugale/scheduling-system/repository"
 	"github.com/chetanugale/scheduling-system/services"
+	"github.com/chetanugale/scheduling-system/tracing"
 )
 
 func main() {
-	router := gin.Default()
-	ctx := context.Background()
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.Getenv)
+	logger := logging.New(os.Stderr, cfg.LogLevel)
+	slog.SetDefault(logger) // also routes the standard log package through it
+	gin.DebugPrintFunc = logging.GinDebug
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fatal("print config", err)
+		}
+	}
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	if cfg.PrintConfig {
+		return
+	}
+	if cfg.JWTSecret == "" && cfg.APIKeys == "" {
+		slog.Warn("neither a JWT secret nor API keys are configured, every API call will be rejected")
+	}
+
+	// cancelled on SIGINT/SIGTERM, which starts the graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TraceExporter, cfg.OTLPEndpoint)
+	if err != nil {
+		fatal("tracing", err)
+	}
 
-	eventService, availService := dbInitializer(ctx)
+	router := gin.New()
+	router.ContextWithFallback = true // spans on the request context reach the services through *gin.Context
+	router.Use(logging.Middleware(logger), logging.Recovery(logger), tracing.Middleware(), metrics.Middleware())
+	router.SetHTMLTemplate(handlers.Templates())
 
-	router = registerApi(router, eventService, availService)
+	client, eventService, availService, guestService, userService, stats, purger := dbInitializer(ctx, cfg)
+	checker := &health.Checker{
+		Ping:    func(ctx context.Context) error { return client.Ping(ctx, readpref.Primary()) },
+		Timeout: constants.READY_TIMEOUT,
+	}
+	go runEvery(ctx, checker, "stats", constants.STATS_INTERVAL, stats.Refresh)
+	go runEvery(ctx, checker, "purge", constants.PURGE_INTERVAL, purger.Purge)
 
-	router.Run(constants.PORT)
+	authenticator := auth.NewAuthenticator(cfg.JWTSecret, auth.ParseAPIKeys(cfg.APIKeys))
 
+	router = registerApi(router, authenticator, checker, eventService, availService, guestService, userService)
+
+	srv := &http.Server{Addr: cfg.Port, Handler: router}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
+
+	slog.Info("listening", "addr", cfg.Port)
+
+	select {
+	case err := <-serveErr:
+		fatal("serve", err)
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process right away
+
+	slog.Info("shutting down, draining requests", "delay", cfg.ShutdownDelay.String(), "timeout", cfg.ShutdownTimeout.String())
+	checker.Drain()
+	// keep serving until load balancers have seen /readyz fail and stopped
+	// sending new requests, which would otherwise be refused
+	time.Sleep(cfg.ShutdownDelay.Duration)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("shutdown", "error", err)
+	}
+	if err := client.Disconnect(shutdownCtx); err != nil {
+		slog.Error("disconnect", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("flush spans", "error", err)
+	}
 }
 
-func registerApi(router *gin.Engine, eventService *services.MongoEventService, availService *services.MongoAvailabilityService) *gin.Engine {
+// fatal logs err and exits, the structured form of log.Fatal.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func registerApi(router *gin.Engine, authenticator *auth.Authenticator, checker *health.Checker, eventService *services.MongoEventService, availService *services.MongoAvailabilityService, guestService *services.MongoGuestService, userService *services.MongoUserService) *gin.Engine {
+	// ----- Probes, no auth
+
+	router.GET("/healthz", handlers.HealthzHandler())      // liveness
+	router.GET("/readyz", handlers.ReadyzHandler(checker)) // Mongo reachable, not draining, worker status
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))   // Prometheus text format
+
+	// ----- Public poll links, no account needed
+
+	polls := router.Group("/polls/:token", handlers.ViewerZone(nil))             // guests pick their zone with ?tz=
+	polls.GET("", handlers.GetPollHandler(guestService))                         // event title and slots
+	polls.POST("/responses", handlers.RespondToPollHandler(guestService))        // answer as a guest, returns the edit token
+	polls.GET("/responses/me", handlers.GetPollResponseHandler(guestService))    // guest's own answers, needs X-Edit-Token
+	polls.PUT("/responses/me", handlers.UpdatePollResponseHandler(guestService)) // change answers, needs X-Edit-Token
+	polls.GET("/page", handlers.PollPageHandler(guestService))                   // HTML checkbox grid
+	polls.POST("/page", handlers.SubmitPollPageHandler(guestService))            // form post back, edit token and CSRF cookies
+
+	// ----- Calendar feeds, the secret token stands for the user
+
+	router.GET("/feeds/:token/calendar.ics", handlers.FeedCalendarHandler(userService, eventService, availService)) // same as /users/:id/calendar.ics
+
+	// times are rendered in ?tz=, else the caller's profile zone, else the event's
+	api := router.Group("", auth.Middleware(authenticator), handlers.ViewerZone(userService))
+
 	// ----- Event management
 
-	router.POST("/events", handlers.CreateEventHandler(eventService))       //create event   // TODO : add validators for duplicate data
-	router.GET("/events", handlers.GetAllEventsHandler(eventService))       // get all events
-	router.GET("/events/:id", handlers.GetEventHandler(eventService))       // get event with ID
-	router.PUT("/events/:id", handlers.UpdateEventHandler(eventService))    // update event with ID
-	router.DELETE("/events/:id", handlers.DeleteEventHandler(eventService)) // delete event with ID
+	api.POST("/events", handlers.CreateEventHandler(eventService))                //create event   // TODO : add validators for duplicate data
+	api.GET("/events", handlers.GetAllEventsHandler(eventService))                // get all events
+	api.GET("/events/search", handlers.SearchEventsHandler(eventService))         // title text, slot date range, status and organizer filters
+	api.GET("/events/:id", handlers.GetEventHandler(eventService))                // get event with ID
+	api.PUT("/events/:id", handlers.UpdateEventHandler(eventService))             // update event with ID
+	api.DELETE("/events/:id", handlers.DeleteEventHandler(eventService))          // delete event with ID, restorable until purged
+	api.POST("/events/:id/restore", handlers.RestoreEventHandler(eventService))   // undo the delete
+	api.POST("/events/:id/finalize", handlers.FinalizeEventHandler(eventService)) // fix the event to a slot
+	api.POST("/events/:id/share", handlers.ShareEventHandler(eventService))       // create a public poll link
+	api.GET("/events/:id/history", handlers.GetEventHistoryHandler(eventService)) // audit trail of the event and its answers, managers only
+	api.GET("/events/:id/occurrences", handlers.OccurrencesHandler(eventService)) // slots expanded by the recurrence rule, ?from=&to=
 
 	// ----- Availability management
 
-	router.POST("/availability", handlers.AddAvailabilityHandler(availService))                 // every user will post availability    // TODO : add validators for duplicate data
-	router.GET("/event/:id/availability", handlers.GetAvailabilityByEventHandler(availService)) // TODO : optimize this API
-	router.PUT("/availability/:id", handlers.UpdateAvailabilityHandler(availService))
-	router.DELETE("/availability/:id", handlers.DeleteAvailabilityHandler(availService))
+	api.POST("/availability", handlers.AddAvailabilityHandler(availService))                 // every user will post availability    // TODO : add validators for duplicate data
+	api.GET("/event/:id/availability", handlers.GetAvailabilityByEventHandler(availService)) // TODO : optimize this API
+	api.PUT("/availability/:id", handlers.UpdateAvailabilityHandler(availService))
+	api.DELETE("/availability/:id", handlers.DeleteAvailabilityHandler(availService))
+	api.GET("/events/:id/matrix", handlers.GetAvailabilityMatrixHandler(availService))   // users x slots grid with totals, ?format=csv
+	api.GET("/events/:id/page", handlers.ResultsPageHandler(eventService, availService)) // HTML results grid, recommended slots highlighted
 
 	// ------ Recommendation
 
-	router.GET("/events/:id/recommend", handlers.RecommendHandler(eventService, availService)) // recommend availability based on id for max users
+	api.GET("/events/:id/recommend", handlers.RecommendHandler(eventService, availService, userService)) // recommend availability based on id for max users, ?asOf= replays a past one
+
+	// ------ User profiles
+
+	api.GET("/users/:id/profile", handlers.GetProfileHandler(userService))    // zone and working hours, "me" for the caller
+	api.PUT("/users/:id/profile", handlers.UpdateProfileHandler(userService)) // own profile, any with the admin role
+
+	// ------ Calendar export
+
+	api.GET("/events/:id/ics", handlers.EventICSHandler(eventService, availService))             // finalized event as iCalendar
+	api.GET("/users/:id/calendar.ics", handlers.UserCalendarHandler(eventService, availService)) // feed of the user's scheduled events
+	api.POST("/users/:id/feed-token", handlers.RotateFeedTokenHandler(userService))              // new secret feed URL, "me" only
+	api.DELETE("/users/:id/feed-token", handlers.RevokeFeedTokenHandler(userService))            // stop the feed URL, own or any for admins
+
+	// ------ Calendar import
+
+	api.POST("/events/import", handlers.ImportEventHandler(eventService))                                       // new event from an .ics / VFREEBUSY upload
+	api.POST("/events/:id/availability/import", handlers.ImportAvailabilityHandler(eventService, availService)) // answer from the caller's free/busy
 
 	return router
 }
 
-func dbInitializer(ctx context.Context) (*services.MongoEventService, *services.MongoAvailabilityService) {
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(constants.MONGO_URI))
+func dbInitializer(ctx context.Context, cfg config.Config) (*mongo.Client, *services.MongoEventService, *services.MongoAvailabilityService, *services.MongoGuestService, *services.MongoUserService, *services.Stats, *services.Purger) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
 	if err != nil {
-		log.Fatal(err)
+		fatal("mongo connect", err)
 	}
+	// Connect is lazy, fail the boot instead of the first request
+	pingCtx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout.Duration)
+	defer cancel()
+	if err := client.Ping(pingCtx, readpref.Primary()); err != nil {
+		slog.Error("mongo not reachable", "timeout", cfg.ConnectTimeout.String(), "error", err)
+		os.Exit(1)
+	}
+
+	db := client.Database(cfg.Database)
+	ran, err := repository.Migrate(ctx, db, cfg.Collections, repository.Migrations)
+	for _, m := range ran {
+		slog.Info("applied migration", "version", m.Version, "name", m.Name)
+	}
+	if err != nil {
+		fatal("migrate", err)
+	}
+	// every query is scoped to the organization resolved by auth.Middleware
+	rawEventRepo := repository.NewCachedRepository(instrumented[models.Event](db, cfg.Collections.Events), cfg.CacheSize, cfg.CacheTTL.Duration)
+	rawAvailRepo := repository.NewCachedRepository(instrumented[models.Availability](db, cfg.Collections.Availability), cfg.CacheSize, cfg.CacheTTL.Duration)
+	eventRepo := repository.NewTenantRepository[models.Event](rawEventRepo)
+	availRepo := repository.NewTenantRepository[models.Availability](rawAvailRepo)
+	guestRepo := repository.NewTenantRepository[models.Guest](instrumented[models.Guest](db, cfg.Collections.Guests))
+	aggregates := repository.NewTenantAvailabilityAggregates(repository.NewInstrumentedAvailabilityAggregates(
+		repository.NewAvailabilityAggregates(db.Collection(cfg.Collections.Availability)), cfg.Collections.Availability))
+	history := repository.NewTenantRevisionHistory(repository.NewInstrumentedRevisionHistory(
+		repository.NewRevisionHistory(db.Collection(cfg.Collections.Revisions)), cfg.Collections.Revisions))
+
+	auditLog := &services.MongoAuditLog{Repo: repository.NewTenantRepository[models.AuditEntry](instrumented[models.AuditEntry](db, cfg.Collections.Audit))}
+	revisions := &services.MongoRevisionLog{Repo: repository.NewTenantRepository[models.Revision](instrumented[models.Revision](db, cfg.Collections.Revisions)), History: history}
 
-	db := client.Database(constants.DB_NAME)
-	eventRepo := repository.NewMongoRepository[models.Event](db.Collection(constants.COLL_EVENTS))
-	availRepo := repository.NewMongoRepository[models.Availability](db.Collection(constants.COLL_AVAIL))
+	eventService := &services.MongoEventService{Repo: eventRepo, Answers: availRepo, Audit: auditLog, Revisions: revisions}
+	availService := &services.MongoAvailabilityService{Repo: availRepo, Aggregates: aggregates, Events: eventRepo, Tallies: services.NewTallyCache(cfg.CacheSize, cfg.CacheTTL.Duration), Audit: auditLog, Revisions: revisions}
+	// share tokens are looked up across organizations, the event then scopes the rest
+	guestService := &services.MongoGuestService{Events: rawEventRepo, Guests: guestRepo, Avail: availService}
+	rawUserRepo := instrumented[models.User](db, cfg.Collections.Users)
+	// feed tokens are looked up across organizations too, the user then scopes the rest
+	userService := &services.MongoUserService{Repo: repository.NewTenantRepository[models.User](rawUserRepo), Feeds: rawUserRepo}
 
-	eventService := &services.MongoEventService{Repo: eventRepo}
-	availService := &services.MongoAvailabilityService{Repo: availRepo}
+	stats := &services.Stats{Events: rawEventRepo, Avail: rawAvailRepo}
+	purger := &services.Purger{Events: rawEventRepo, Avail: rawAvailRepo, Retention: cfg.Retention.Duration}
 
-	return eventService, availService
+	return client, eventService, availService, guestService, userService, stats, purger
+}
+
+// instrumented is the Mongo repository of a collection with its calls timed on /metrics.
+func instrumented[T any](db *mongo.Database, coll string) repository.MongoRepository[T] {
+	return repository.NewInstrumentedRepository(repository.NewMongoRepository[T](db.Collection(coll)), coll)
+}
+
+// runEvery runs fn now and then every interval until ctx is done, reporting
+// each run to the readiness probe under name.
+func runEvery(ctx context.Context, checker *health.Checker, name string, interval time.Duration, fn func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := fn(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "worker run failed", "worker", name, "error", err)
+		}
+		checker.ReportRun(name, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
@@ -0,0 +1,69 @@
+// Command migrate applies pending schema migrations and exits, for running
+// ahead of a deploy. The server also applies them at startup. It reads the
+// same configuration as the server.
+//
+//	go run ./cmd/migrate           apply pending migrations
+//	go run ./cmd/migrate -status   list pending migrations only
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/chetanugale/scheduling-system/config"
+	"github.com/chetanugale/scheduling-system/logging"
+	"github.com/chetanugale/scheduling-system/repository"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	status := flag.Bool("status", false, "list pending migrations without applying them")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.Getenv)
+	slog.SetDefault(logging.New(os.Stderr, cfg.LogLevel))
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		fatal("mongo connect", err)
+	}
+	defer client.Disconnect(ctx)
+	db := client.Database(cfg.Database)
+
+	if *status {
+		todo, err := repository.Pending(ctx, db, cfg.Collections, repository.Migrations)
+		if err != nil {
+			fatal("list pending migrations", err)
+		}
+		if len(todo) == 0 {
+			fmt.Println("up to date")
+		}
+		for _, m := range todo {
+			fmt.Printf("pending %d: %s\n", m.Version, m.Name)
+		}
+		return
+	}
+
+	ran, err := repository.Migrate(ctx, db, cfg.Collections, repository.Migrations)
+	for _, m := range ran {
+		fmt.Printf("applied %d: %s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		fatal("migrate", err)
+	}
+	if len(ran) == 0 {
+		fmt.Println("up to date")
+	}
+}
+
+// fatal logs err and exits, the structured form of log.Fatal.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func load(args []string, vars map[string]string) (Config, error) {
+	return Load(flag.NewFlagSet("test", flag.ContinueOnError), args, env(vars))
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := load(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("database: fromfile\nport: \":9000\"\ncacheSize: 5\ncacheTTL: 1m\ncollections:\n  events: ev\n"), 0o600))
+
+	cfg, err := load([]string{"-config", file, "-port", ":9002"}, map[string]string{
+		EnvPort:            ":9001",
+		EnvJWTSecret:       "secret",
+		EnvCacheTTL:        "2m",
+		EnvShutdownTimeout: "1m",
+		EnvShutdownDelay:   "0s",
+		EnvLogLevel:        "debug",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "fromfile", cfg.Database)
+	assert.Equal(t, ":9002", cfg.Port)
+	assert.Equal(t, "secret", cfg.JWTSecret)
+	assert.Equal(t, 5, cfg.CacheSize)
+	assert.Equal(t, 2*time.Minute, cfg.CacheTTL.Duration)
+	assert.Equal(t, time.Minute, cfg.ShutdownTimeout.Duration)
+	assert.Zero(t, cfg.ShutdownDelay.Duration)
+	assert.Equal(t, Default().ConnectTimeout, cfg.ConnectTimeout)
+	assert.Equal(t, slog.LevelDebug, cfg.LogLevel)
+	assert.Equal(t, "ev", cfg.Collections.Events)
+	assert.Equal(t, Default().Collections.Guests, cfg.Collections.Guests)
+}
+
+func TestLoadFlags(t *testing.T) {
+	cfg, err := load([]string{
+		"-connect-timeout", "3s",
+		"-shutdown-timeout", "1m",
+		"-shutdown-delay", "0s",
+		"-retention", "48h",
+		"-otlp-endpoint", "http://collector:4318",
+		"-collection-events", "events_v2",
+		"-collection-users", "people",
+	}, map[string]string{EnvShutdownDelay: "10s"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, cfg.ConnectTimeout.Duration)
+	assert.Equal(t, time.Minute, cfg.ShutdownTimeout.Duration)
+	assert.Zero(t, cfg.ShutdownDelay.Duration)
+	assert.Equal(t, 48*time.Hour, cfg.Retention.Duration)
+	assert.Equal(t, "http://collector:4318", cfg.OTLPEndpoint)
+	assert.Equal(t, "events_v2", cfg.Collections.Events)
+	assert.Equal(t, "people", cfg.Collections.Users)
+	assert.Equal(t, Default().Collections.Guests, cfg.Collections.Guests)
+}
+
+func TestLoadJSONFileFromEnv(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, os.WriteFile(file, []byte(`{"database": "fromjson", "cacheTTL": "10s"}`), 0o600))
+
+	cfg, err := load(nil, map[string]string{EnvConfigFile: file})
+	assert.NoError(t, err)
+	assert.Equal(t, "fromjson", cfg.Database)
+	assert.Equal(t, 10*time.Second, cfg.CacheTTL.Duration)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	_, err := load([]string{"-mongo-uri", "localhost", "-port", "8080", "-cache-size", "0"}, nil)
+	assert.ErrorContains(t, err, "mongoUri")
+	assert.ErrorContains(t, err, "port")
+	assert.ErrorContains(t, err, "cacheSize")
+
+	_, err = load([]string{"-shutdown-delay", "-1s"}, nil)
+	assert.ErrorContains(t, err, "shutdownDelay")
+
+	_, err = load([]string{"-collection-guests", "events"}, nil)
+	assert.ErrorContains(t, err, "distinct")
+
+	_, err = load([]string{"-trace-exporter", "jaeger"}, nil)
+	assert.ErrorContains(t, err, "traceExporter")
+
+	_, err = load(nil, map[string]string{EnvLogLevel: "chatty"})
+	assert.ErrorContains(t, err, EnvLogLevel)
+
+	_, err = load(nil, map[string]string{EnvCacheTTL: "soon"})
+	assert.ErrorContains(t, err, EnvCacheTTL)
+
+	_, err = load(nil, map[string]string{EnvRetention: "-1h"})
+	assert.ErrorContains(t, err, "retention")
+
+	_, err = load(nil, map[string]string{EnvShutdownDelay: "-1s"})
+	assert.ErrorContains(t, err, "shutdownDelay")
+
+	file := filepath.Join(t.TempDir(), "config.toml")
+	assert.NoError(t, os.WriteFile(file, nil, 0o600))
+	_, err = load([]string{"-config", file}, nil)
+	assert.Error(t, err)
+}
+
+func TestValidateCollections(t *testing.T) {
+	cfg := Default()
+	cfg.Collections.Guests = cfg.Collections.Events
+	assert.ErrorContains(t, cfg.Validate(), "distinct")
+}
+
+func TestPrintRedacts(t *testing.T) {
+	cfg, err := load([]string{"-print-config", "-mongo-uri", "mongodb://app:hunter2@db:27017"}, map[string]string{
+		EnvJWTSecret: "jwt-secret",
+		EnvAPIKeys:   "key1:user1",
+	})
+	assert.NoError(t, err)
+	assert.True(t, cfg.PrintConfig)
+
+	var out bytes.Buffer
+	assert.NoError(t, cfg.Print(&out))
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.NotContains(t, out.String(), "jwt-secret")
+	assert.NotContains(t, out.String(), "key1")
+	assert.Contains(t, out.String(), "mongodb://app:<redacted>@db:27017")
+	assert.Contains(t, out.String(), "cacheTTL: 30s")
+	assert.Contains(t, out.String(), "logLevel: INFO")
+	assert.Equal(t, "jwt-secret", cfg.JWTSecret)
+}
@@ -1,9 +1,35 @@
 package constants
 
+import (
+	"log/slog"
+	"time"
+)
+
+// Defaults of the settings in package config, which can override every one of them.
 const(
-	MONGO_URI = ""
+	MONGO_URI = "mongodb://localhost:27017"
 	DB_NAME = "scheduler"
 	PORT = ":8080"
 	COLL_EVENTS="events"
 	COLL_AVAIL="availabilities"
+	COLL_GUESTS="guests"
+	COLL_MIGRATIONS="migrations"
+	COLL_AUDIT="audit"
+	COLL_REVISIONS="revisions"
+	COLL_USERS="users"
+
+	CACHE_SIZE = 10000            // documents kept per collection, and events with cached tallies
+	CACHE_TTL = 30 * time.Second  // upper bound on staleness from writes by other instances
+
+	CONNECT_TIMEOUT = 10 * time.Second   // boot fails if Mongo does not answer by then
+	SHUTDOWN_TIMEOUT = 15 * time.Second  // in-flight requests get this long to finish on SIGTERM
+	SHUTDOWN_DELAY = 5 * time.Second     // still serving while load balancers see /readyz fail
+	READY_TIMEOUT = 2 * time.Second      // database ping of the readiness probe
+	STATS_INTERVAL = time.Minute         // refresh of the business gauges on /metrics
+
+	DELETE_RETENTION = 30 * 24 * time.Hour  // deleted events and answers can be restored for this long
+	PURGE_INTERVAL = time.Hour              // how often documents past the retention are removed
+
+	TRACE_EXPORTER = "none"  // none, stdout or otlp
+	LOG_LEVEL = slog.LevelInfo
 )
@@ -1,17 +1,57 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/metrics"
 	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
 	"github.com/chetanugale/scheduling-system/services"
+	"github.com/chetanugale/scheduling-system/tenant"
+	"github.com/chetanugale/scheduling-system/tracing"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// errorStatus maps authorization errors from the services to HTTP codes and
+// falls back to the handler's own status for everything else.
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, services.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidSlot), errors.Is(err, services.ErrInvalidName), errors.Is(err, tenant.ErrNoOrg):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrEmptyCalendar), errors.Is(err, services.ErrTooManySlots):
+		return http.StatusBadRequest
+	case errors.Is(err, query.ErrInvalidCursor), errors.Is(err, query.ErrUnknownField), errors.Is(err, services.ErrInvalidSearch):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrInvalidRecurrence), errors.Is(err, services.ErrInvalidWindow):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrInvalidTimeZone), errors.Is(err, services.ErrInvalidWorkHours):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrInvalidEditToken), errors.Is(err, services.ErrInvalidFeedToken):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrPollNotFound), errors.Is(err, services.ErrNoRevision):
+		return http.StatusNotFound
+	case errors.Is(err, mongo.ErrNoDocuments): // missing or deleted
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPollClosed), errors.Is(err, services.ErrNotFinalized):
+		return http.StatusConflict
+	}
+	return fallback
+}
+
 func CreateEventHandler(svc services.EventService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var e models.Event
@@ -27,9 +67,10 @@ func CreateEventHandler(svc services.EventService) gin.HandlerFunc {
 
 		created, err := svc.CreateEvent(c, e)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, bson.M{"error": err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
 			return
 		}
+		localize(c, created)
 		c.JSON(http.StatusOK, created)
 	}
 }
@@ -42,6 +83,7 @@ func GetEventHandler(svc services.EventService) gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, bson.M{"error": "Event not found"})
 			return
 		}
+		localize(c, event)
 		c.JSON(http.StatusOK, event)
 	}
 }
@@ -49,12 +91,106 @@ func GetEventHandler(svc services.EventService) gin.HandlerFunc {
 func GetAllEventsHandler(svc services.EventService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		title := c.Query("title")
-		event, err := svc.GetAllEvents(c, title)
+		opts, err := findOptions(c)
 		if err != nil {
-			c.JSON(http.StatusNotFound, bson.M{"error": "Events not found.Empty Dataset"})
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, event)
+		page, err := listPage(c, opts, func(opts query.FindOptions) (*query.Page[models.Event], error) {
+			return svc.GetAllEvents(c, title, opts)
+		})
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusNotFound), bson.M{"error": "Events not found.Empty Dataset"})
+			return
+		}
+		localizePage(c, page.Items)
+		out, err := projectPage(page, opts.Fields, !pageRequested(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// SearchEventsHandler lists events matching q (title words), from and to (a
+// slot starting in [from, to), RFC 3339 or YYYY-MM-DD), status and organizer.
+func SearchEventsHandler(svc services.EventService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		search := models.EventSearch{
+			Text:      c.Query("q"),
+			Status:    c.Query("status"),
+			Organizer: c.Query("organizer"),
+		}
+		var err error
+		if search.From, err = timeParam(c, "from"); err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		if search.To, err = timeParam(c, "to"); err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		opts, err := findOptions(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		page, err := svc.SearchEvents(c, search, opts)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
+		}
+		localizePage(c, page.Items)
+		out, err := projectPage(page, opts.Fields, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// occurrenceWindow is how far GET /events/:id/occurrences looks ahead without
+// ?to=, and maxOccurrenceWindow how far it may look.
+const (
+	occurrenceWindow    = 90 * 24 * time.Hour
+	maxOccurrenceWindow = 366 * 24 * time.Hour
+)
+
+// OccurrencesHandler expands the slots of an event into concrete times
+// between ?from= (now by default) and ?to=.
+func OccurrencesHandler(svc services.EventService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, err := timeParam(c, "from")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		to, err := timeParam(c, "to")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		if from.IsZero() {
+			from = time.Now().UTC()
+		}
+		if to.IsZero() {
+			to = from.Add(occurrenceWindow)
+		}
+		if to.Sub(from) > maxOccurrenceWindow {
+			c.JSON(http.StatusBadRequest, bson.M{"error": "the window can span at most 366 days"})
+			return
+		}
+		occurrences, err := svc.GetOccurrences(c, c.Param("id"), from, to)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusNotFound), bson.M{"error": err.Error()})
+			return
+		}
+		if loc := viewerZone(c); loc != nil {
+			occurrences = inZone(occurrences, loc)
+		}
+		c.JSON(http.StatusOK, occurrences)
 	}
 }
 
@@ -66,13 +202,14 @@ func UpdateEventHandler(svc services.EventService) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
 			return
 		}
-		err := svc.UpdateEvent(c, id, event)
+		updated, err := svc.UpdateEvent(c, id, event)
 		if err != nil {
-			log.Fatalf("%+v", err)
-			c.JSON(http.StatusInternalServerError, bson.M{"error": "Error while updating event."})
+			slog.ErrorContext(c, "update event", "eventId", id, "error", err)
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": "Error while updating event."})
 			return
 		}
-		c.JSON(http.StatusOK, event)
+		localize(c, updated)
+		c.JSON(http.StatusOK, updated)
 	}
 }
 
@@ -80,13 +217,47 @@ func DeleteEventHandler(svc services.EventService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 		if err := svc.DeleteEvent(c, id); err != nil {
-			c.JSON(http.StatusInternalServerError, bson.M{"error": err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
 			return
 		}
 		c.Status(http.StatusNoContent)
 	}
 }
 
+// RestoreEventHandler brings back a deleted event with its answers, until the
+// retention period is over.
+func RestoreEventHandler(svc services.EventService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		event, err := svc.RestoreEvent(c, c.Param("id"))
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusNotFound), bson.M{"error": err.Error()})
+			return
+		}
+		localize(c, event)
+		c.JSON(http.StatusOK, event)
+	}
+}
+
+func FinalizeEventHandler(svc services.EventService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		var req struct {
+			SlotID string `json:"slotId" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		event, err := svc.FinalizeEvent(c, id, req.SlotID)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusNotFound), bson.M{"error": err.Error()})
+			return
+		}
+		localize(c, event)
+		c.JSON(http.StatusOK, event)
+	}
+}
+
 func AddAvailabilityHandler(svc services.AvailabilityService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var a models.Availability
@@ -94,10 +265,16 @@ func AddAvailabilityHandler(svc services.AvailabilityService) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
 			return
 		}
+		caller, ok := auth.FromContext(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, bson.M{"error": auth.ErrMissingCredentials.Error()})
+			return
+		}
+		a.UserID = caller.UserID                  // never trust the userId sent in the body
 		a.ID = primitive.NewObjectID()            // TODO : optimize and cleanup later
-		created, err := svc.AddAvailability(c, a) // TODO : validate if eventId and SlotId present
+		created, err := svc.AddAvailability(c, a)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, bson.M{"error": err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, created)
@@ -107,11 +284,50 @@ func AddAvailabilityHandler(svc services.AvailabilityService) gin.HandlerFunc {
 func GetAvailabilityByEventHandler(svc services.AvailabilityService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		eventId := c.Param("id")
-		availList, err := svc.GetAvailabilitiesByEvent(c, eventId)
+		if wantsFormat(c, "ndjson", "application/x-ndjson") {
+			streamNDJSON(c, svc.StreamAvailabilitiesByEvent(c, eventId))
+			return
+		}
+		opts, err := findOptions(c)
 		if err != nil {
-			c.JSON(http.StatusNotFound, fmt.Sprintf("%+v", err.Error()))
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
 		}
-		c.JSON(http.StatusOK, availList)
+		page, err := listPage(c, opts, func(opts query.FindOptions) (*query.Page[models.Availability], error) {
+			return svc.ListAvailabilitiesByEvent(c, eventId, opts)
+		})
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusNotFound), fmt.Sprintf("%+v", err.Error()))
+			return
+		}
+		out, err := projectPage(page, opts.Fields, !pageRequested(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// GetEventHistoryHandler lists the audit entries of an event and its answers.
+func GetEventHistoryHandler(svc services.EventService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts, err := findOptions(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		page, err := svc.GetEventHistory(c, c.Param("id"), opts)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusNotFound), bson.M{"error": err.Error()})
+			return
+		}
+		out, err := projectPage(page, opts.Fields, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, out)
 	}
 }
 
@@ -119,7 +335,7 @@ func DeleteAvailabilityHandler(svc services.AvailabilityService) gin.HandlerFunc
 	return func(c *gin.Context) {
 		id := c.Param("id")
 		if err := svc.DeleteAvailability(c, id); err != nil {
-			c.JSON(http.StatusInternalServerError, bson.M{"error": err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
 			return
 		}
 		c.Status(http.StatusNoContent)
@@ -134,17 +350,17 @@ func UpdateAvailabilityHandler(svc services.AvailabilityService) gin.HandlerFunc
 			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
 			return
 		}
-		err := svc.UpdateAvailability(c, id, avail)
+		updated, err := svc.UpdateAvailability(c, id, avail)
 		if err != nil {
-			log.Fatalf("%+v", err)
-			c.JSON(http.StatusInternalServerError, bson.M{"error": "Error while updating availability."})
+			slog.ErrorContext(c, "update availability", "availabilityId", id, "error", err)
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": "Error while updating availability."})
 			return
 		}
-		c.JSON(http.StatusOK, avail)
+		c.JSON(http.StatusOK, updated)
 	}
 }
 
-func processRecommendations(event models.Event, available []models.Availability) ([]models.TimeSlot, map[string][]string, error) {
+func processRecommendations(event models.Event, tallies models.SlotTallies) ([]models.TimeSlot, map[string][]string, error) {
 	// event
 	// {
 	// 	"title":"test6",
@@ -163,18 +379,16 @@ func processRecommendations(event models.Event, available []models.Availability)
 	// 	]
 	// }
 
-	//availability
+	//tallies, counted per slot by the aggregation in AvailabilityService.TallySlots
 	// {
-	//     "eventId":"6817a28c6d1b32a2fd46ec16",
-	//     "slotId":"6817a28c6d1b32a2fd46ec14",
-	//     "userId":"abcd"
+	//     "slots":[{"slotId":"pqrs","users":["abcd"],"count":1}],
+	//     "users":["abcd","efgh"]
 	// }
 	//target : timeslot which has max no of users available for event, list of users not available for max timeslot
-	//1. fetch users for matching slotid and eventid and reverse the query for not matching timeslots
 
-	slotList := map[string][]string{}
-	for _, avail := range available {
-		slotList[avail.SlotID.String()] = append(slotList[avail.SlotID.String()], avail.UserID)
+	slotList := map[primitive.ObjectID]models.SlotTally{}
+	for _, tally := range tallies.Slots {
+		slotList[tally.SlotID] = tally
 	}
 
 	var preciseUsers int
@@ -182,26 +396,19 @@ func processRecommendations(event models.Event, available []models.Availability)
 	userSlotsList := map[string][]string{}
 
 	for _, slot := range event.Slots {
-		users := slotList[slot.ID.String()]
-		if len(users) > preciseUsers {
-			preciseUsers = len(users)
+		count := slotList[slot.ID].Count
+		if count > preciseUsers {
+			preciseUsers = count
 			idealSlots = []models.TimeSlot{slot}
-		} else if len(users) == preciseUsers {
+		} else if count == preciseUsers {
 			idealSlots = append(idealSlots, slot)
 		}
 	}
 
-	usersSet := map[string]struct{}{}
-	for _, a := range available {
-		usersSet[a.UserID] = struct{}{}
-	}
 	for _, slot := range idealSlots {
-		presentUser := map[string]bool{}
-		for _, userid := range slotList[slot.ID.String()] {
-			presentUser[userid] = true
-		}
-		for userid := range usersSet {
-			if !presentUser[userid] {
+		present := slotList[slot.ID].Users
+		for _, userid := range tallies.Users {
+			if !slices.Contains(present, userid) {
 				userSlotsList[slot.ID.Hex()] = append(userSlotsList[slot.ID.Hex()], userid)
 			}
 		}
@@ -209,21 +416,91 @@ func processRecommendations(event models.Event, available []models.Availability)
 	return idealSlots, userSlotsList, nil
 }
 
-func RecommendHandler(svcEvent services.EventService, svcAvail services.AvailabilityService) gin.HandlerFunc {
+// RecommendHandler picks the slots most participants can attend, and flags
+// those outside the local working hours of some of them. With
+// ?asOf=<timestamp> it replays the recommendation from the event and answers
+// as they stood at that time.
+func RecommendHandler(svcEvent services.EventService, svcAvail services.AvailabilityService, svcUser services.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		eventId := c.Param("id")
+		asOf, err := timeParam(c, "asOf")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		if !asOf.IsZero() {
+			event, tallies, err := svcAvail.TallySlotsAsOf(c, eventId, asOf)
+			if err != nil {
+				c.JSON(errorStatus(err, http.StatusNotFound), bson.M{"error": err.Error()})
+				return
+			}
+			idealSlots, notfeasible, err := processRecommendations(*event, *tallies)
+			if err != nil {
+				c.JSON(http.StatusPreconditionFailed, fmt.Sprintf("%+v", err.Error()))
+				return
+			}
+			outside, err := outsideWorkingHours(c, svcUser, idealSlots, tallies.Users)
+			if err != nil {
+				c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+				return
+			}
+			// the replayed data, to show what the decision was based on
+			localize(c, event)
+			c.JSON(http.StatusOK, gin.H{
+				"IdealSlots":          inZone(idealSlots, zoneFor(c, *event)),
+				"NotFeasibleforUsers": notfeasible,
+				"OutsideWorkingHours": outside,
+				"AsOf":                asOf,
+				"Event":               event,
+				"Tallies":             tallies,
+			})
+			return
+		}
+
 		event, err := svcEvent.GetEvent(c, eventId)
 		if err != nil {
 			c.JSON(http.StatusNotFound, fmt.Sprintf("%+v", err.Error()))
+			return
+		}
+		// recommendations expose every participant's answers
+		if user, ok := auth.FromContext(c); !ok || !services.CanManageEvent(user, *event) {
+			c.JSON(http.StatusForbidden, bson.M{"error": services.ErrForbidden.Error()})
+			return
 		}
-		availList, err := svcAvail.GetAvailabilitiesByEvent(c, eventId)
+		start := time.Now()
+		tallies, err := svcAvail.TallySlots(c, eventId)
 		if err != nil {
-			c.JSON(http.StatusNotFound, fmt.Sprintf("%+v", err.Error()))
+			c.JSON(errorStatus(err, http.StatusNotFound), fmt.Sprintf("%+v", err.Error()))
+			return
 		}
-		idealSlots, notfeasible, err := processRecommendations(*event, availList) // TODO : Optimize this function
+		_, span := tracing.Start(c, "processRecommendations", attribute.Int("slots", len(event.Slots)), attribute.Int("users", len(tallies.Users)))
+		idealSlots, notfeasible, err := processRecommendations(*event, *tallies)
+		tracing.End(span, err)
+		metrics.ObserveRecommend(start) // tallying and picking, not the profile lookup below
 		if err != nil {
 			c.JSON(http.StatusPreconditionFailed, fmt.Sprintf("%+v", err.Error()))
+			return
+		}
+		outside, err := outsideWorkingHours(c, svcUser, idealSlots, tallies.Users)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
 		}
-		c.JSON(http.StatusOK, gin.H{"IdealSlots": idealSlots, "NotFeasibleforUsers": notfeasible})
+		c.JSON(http.StatusOK, gin.H{
+			"IdealSlots":          inZone(idealSlots, zoneFor(c, *event)),
+			"NotFeasibleforUsers": notfeasible,
+			"OutsideWorkingHours": outside,
+		})
+	}
+}
+
+// outsideWorkingHours maps the id of each of slots to the users, among those
+// who answered, for whom it falls outside their working day. Profiles are
+// read as they are now, also when replaying a past recommendation.
+func outsideWorkingHours(c *gin.Context, svcUser services.UserService, slots []models.TimeSlot, users []string) (map[string][]string, error) {
+	profiles, err := svcUser.GetProfiles(c, users)
+	if err != nil {
+		return nil, err
 	}
+	return services.OutsideWorkingHours(slots, profiles), nil
 }
@@ -2,19 +2,35 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"iter"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/health"
 	"github.com/chetanugale/scheduling-system/mocker"
 	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/chetanugale/scheduling-system/services"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+func withIdentity(userID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth.SetIdentity(c, auth.Identity{UserID: userID, Method: "jwt"})
+	}
+}
+
 // --------- GET /events/:id/recommend -----------
 
 func TestRecommendHandler(t *testing.T) {
@@ -23,19 +39,23 @@ func TestRecommendHandler(t *testing.T) {
 	slotID := primitive.NewObjectID()
 	mockEventSvc := new(mocker.MockEventService)
 	mockAvailSvc := new(mocker.MockAvailabilityService)
+	mockUserSvc := new(mocker.MockUserService)
 
 	event := &models.Event{
 		ID:    primitive.NewObjectID(),
 		Title: "Mock Event",
-		Slots: []models.TimeSlot{{ID: slotID}},
+		Slots:      []models.TimeSlot{{ID: slotID}},
+		Organizers: []string{"u1"},
 	}
 
 	mockEventSvc.On("GetEvent", mock.Anything, "abc123").Return(event, nil)
-	mockAvailSvc.On("GetAvailabilitiesByEvent", mock.Anything, "abc123").
-		Return([]models.Availability{{UserID: "u1", SlotID: slotID}}, nil)
+	mockAvailSvc.On("TallySlots", mock.Anything, "abc123").
+		Return(&models.SlotTallies{Slots: []models.SlotTally{{SlotID: slotID, Users: []string{"u1"}, Count: 1}}, Users: []string{"u1"}}, nil)
+	mockUserSvc.On("GetProfiles", mock.Anything, []string{"u1"}).Return([]models.User{}, nil)
 
 	router := gin.New()
-	router.GET("/events/:id/recommend", RecommendHandler(mockEventSvc, mockAvailSvc))
+	router.Use(withIdentity("u1"))
+	router.GET("/events/:id/recommend", RecommendHandler(mockEventSvc, mockAvailSvc, mockUserSvc))
 
 	req, _ := http.NewRequest(http.MethodGet, "/events/abc123/recommend", nil)
 	resp := httptest.NewRecorder()
@@ -45,6 +65,70 @@ func TestRecommendHandler(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.Code)
 	mockEventSvc.AssertExpectations(t)
 	mockAvailSvc.AssertExpectations(t)
+	mockUserSvc.AssertExpectations(t)
+}
+
+func TestRecommendHandlerParticipantForbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockEventSvc := new(mocker.MockEventService)
+	mockAvailSvc := new(mocker.MockAvailabilityService)
+	mockUserSvc := new(mocker.MockUserService)
+
+	event := &models.Event{ID: primitive.NewObjectID(), Organizers: []string{"owner"}}
+	mockEventSvc.On("GetEvent", mock.Anything, "abc123").Return(event, nil)
+
+	router := gin.New()
+	router.Use(withIdentity("u2"))
+	router.GET("/events/:id/recommend", RecommendHandler(mockEventSvc, mockAvailSvc, mockUserSvc))
+
+	req, _ := http.NewRequest(http.MethodGet, "/events/abc123/recommend", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	mockAvailSvc.AssertNotCalled(t, "TallySlots", mock.Anything, mock.Anything)
+}
+
+func TestRecommendHandlerAsOf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	slotID := primitive.NewObjectID()
+	asOf := time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC)
+	mockEventSvc := new(mocker.MockEventService)
+	mockAvailSvc := new(mocker.MockAvailabilityService)
+	mockUserSvc := new(mocker.MockUserService)
+
+	// 12:00 UTC is 21:00 in Tokyo
+	slot := models.TimeSlot{ID: slotID, StartTime: time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 5, 1, 13, 0, 0, 0, time.UTC)}
+	past := &models.Event{ID: primitive.NewObjectID(), Title: "before the rename", Slots: []models.TimeSlot{slot}}
+	tallies := &models.SlotTallies{Slots: []models.SlotTally{{SlotID: slotID, Users: []string{"u1"}, Count: 1}}, Users: []string{"u1"}}
+	mockAvailSvc.On("TallySlotsAsOf", mock.Anything, "abc123", asOf).Return(past, tallies, nil)
+	mockUserSvc.On("GetProfiles", mock.Anything, []string{"u1"}).Return([]models.User{{UserID: "u1", TimeZone: "Asia/Tokyo", WorkStart: "09:00", WorkEnd: "17:00"}}, nil)
+	mockAvailSvc.On("TallySlotsAsOf", mock.Anything, "new", mock.Anything).Return((*models.Event)(nil), (*models.SlotTallies)(nil), services.ErrNoRevision)
+
+	router := gin.New()
+	router.Use(withIdentity("u1"))
+	router.GET("/events/:id/recommend", RecommendHandler(mockEventSvc, mockAvailSvc, mockUserSvc))
+
+	req, _ := http.NewRequest(http.MethodGet, "/events/abc123/recommend?asOf=2025-05-02T09:00:00Z", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "before the rename")
+	assert.Contains(t, resp.Body.String(), `"OutsideWorkingHours":{"`+slotID.Hex()+`":["u1"]}`)
+	mockEventSvc.AssertNotCalled(t, "GetEvent", mock.Anything, mock.Anything)
+
+	for url, code := range map[string]int{
+		"/events/new/recommend?asOf=2025-05-02":     http.StatusNotFound,
+		"/events/abc123/recommend?asOf=last+friday": http.StatusBadRequest,
+	} {
+		req, _ = http.NewRequest(http.MethodGet, url, nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, code, resp.Code, url)
+	}
 }
 
 // --------- POST /events -----------
@@ -70,6 +154,30 @@ func TestCreateEventHandler(t *testing.T) {
 	mockSvc.AssertExpectations(t)
 }
 
+// --------- POST /events/:id/finalize -----------
+
+func TestFinalizeEventHandlerForbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockEventService)
+
+	id := primitive.NewObjectID().Hex()
+	slotID := primitive.NewObjectID().Hex()
+	mockSvc.On("FinalizeEvent", mock.Anything, id, slotID).Return((*models.Event)(nil), services.ErrForbidden)
+
+	router := gin.New()
+	router.POST("/events/:id/finalize", FinalizeEventHandler(mockSvc))
+
+	body, _ := json.Marshal(map[string]string{"slotId": slotID})
+	req, _ := http.NewRequest(http.MethodPost, "/events/"+id+"/finalize", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	mockSvc.AssertExpectations(t)
+}
+
 // --------- GET /events/:id -----------
 
 func TestGetEventHandler(t *testing.T) {
@@ -138,16 +246,44 @@ func TestUpdateEventHandler(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, resp.Code)
 }
 
+func TestUpdateEventHandlerReturnsStoredEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockEventService)
+
+	id := primitive.NewObjectID()
+	stored := &models.Event{ID: id, Title: "Demo", Status: models.EventStatusFinalized, Organizers: []string{"u1"}}
+	mockSvc.On("UpdateEvent", mock.Anything, id.Hex(), mock.Anything).Return(stored, nil)
+
+	router := gin.New()
+	router.PUT("/events/:id", UpdateEventHandler(mockSvc))
+
+	body, _ := json.Marshal(models.Event{Title: "Demo", Status: "open"})
+	req, _ := http.NewRequest(http.MethodPut, "/events/"+id.Hex(), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var got models.Event
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &got))
+	assert.Equal(t, id, got.ID)
+	assert.Equal(t, models.EventStatusFinalized, got.Status)
+	assert.Equal(t, []string{"u1"}, got.Organizers)
+}
+
 // --------- GET /events -----------
 
 func TestGetAllEventHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := new(mocker.MockEventService)
 
-	event := &models.Event{Title: "Demo", ID: primitive.NewObjectID()}
-	eventList := &[]models.Event{*event}
-
-	mockSvc.On("GetAllEvents", mock.Anything, mock.Anything).Return(*eventList, nil)
+	first := models.Event{Title: "Demo", ID: primitive.NewObjectID()}
+	second := models.Event{Title: "Retro", ID: primitive.NewObjectID()}
+	mockSvc.On("GetAllEvents", mock.Anything, "", query.FindOptions{Limit: query.MaxPageSize}).
+		Return(&query.Page[models.Event]{Items: []models.Event{first}, Next: "p2", Total: 2}, nil)
+	mockSvc.On("GetAllEvents", mock.Anything, "", query.FindOptions{Limit: query.MaxPageSize, After: "p2"}).
+		Return(&query.Page[models.Event]{Items: []models.Event{second}, Total: 2}, nil)
 
 	router := gin.New()
 	router.GET("/events", GetAllEventsHandler(mockSvc))
@@ -157,7 +293,83 @@ func TestGetAllEventHandler(t *testing.T) {
 
 	router.ServeHTTP(resp, req)
 
+	// without limit or next, every event in a bare array, as before paging
 	assert.Equal(t, http.StatusOK, resp.Code)
+	var body []models.Event
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, []string{"Demo", "Retro"}, []string{body[0].Title, body[1].Title})
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetAllEventHandlerPaging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockEventService)
+
+	event := models.Event{Title: "Demo", ID: primitive.NewObjectID(), EstimatedMins: 30}
+	page := &query.Page[models.Event]{Items: []models.Event{event}, Next: "abc", Total: 3}
+	opts := query.FindOptions{
+		Limit:  1,
+		After:  "xyz",
+		Sort:   []query.SortField{{Field: "title", Desc: true}},
+		Fields: []string{"title"},
+	}
+	mockSvc.On("GetAllEvents", mock.Anything, "", opts).Return(page, nil)
+	mockSvc.On("GetAllEvents", mock.Anything, "", mock.Anything).Return((*query.Page[models.Event])(nil), query.ErrInvalidCursor)
+
+	router := gin.New()
+	router.GET("/events", GetAllEventsHandler(mockSvc))
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/events?limit=1&next=xyz&sort=-title&fields=title", nil)
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"items":[{"id":"`+event.ID.Hex()+`","title":"Demo"}],"next":"abc","total":3}`, resp.Body.String())
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/events?limit=0", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/events?next=bogus", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+// --------- GET /events/search -----------
+
+func TestSearchEventsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockEventService)
+
+	search := models.EventSearch{
+		Text:      "retro",
+		From:      time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC),
+		To:        time.Date(2025, 5, 12, 9, 30, 0, 0, time.UTC),
+		Status:    models.EventStatusOpen,
+		Organizer: "alice",
+	}
+	mockSvc.On("SearchEvents", mock.Anything, search, query.FindOptions{Limit: 10}).Return(&query.Page[models.Event]{Items: []models.Event{}}, nil)
+	mockSvc.On("SearchEvents", mock.Anything, mock.Anything, mock.Anything).Return((*query.Page[models.Event])(nil), services.ErrInvalidSearch)
+
+	router := gin.New()
+	router.GET("/events/search", SearchEventsHandler(mockSvc))
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/events/search?q=retro&from=2025-05-05&to=2025-05-12T09:30:00Z&status=open&organizer=alice&limit=10", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/events/search?from=last-week", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/events/search?status=cancelled", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
 }
 
 // --------- POST /availability -----------
@@ -165,11 +377,13 @@ func TestCreateAvailability(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := new(mocker.MockAvailabilityService)
 
-	event := models.Availability{EventID: primitive.NewObjectID()}
-	mockSvc.On("AddAvailability", mock.Anything, mock.AnythingOfType("Availability")).
-		Return(&event, nil)
+	event := models.Availability{EventID: primitive.NewObjectID(), UserID: "spoofed"}
+	mockSvc.On("AddAvailability", mock.Anything, mock.MatchedBy(func(a models.Availability) bool {
+		return a.UserID == "u1"
+	})).Return(&event, nil)
 
 	router := gin.New()
+	router.Use(withIdentity("u1"))
 	router.POST("/availability", AddAvailabilityHandler(mockSvc))
 
 	body, _ := json.Marshal(event)
@@ -183,6 +397,48 @@ func TestCreateAvailability(t *testing.T) {
 	mockSvc.AssertExpectations(t)
 }
 
+func TestCreateAvailabilityUnauthenticated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockAvailabilityService)
+
+	router := gin.New()
+	router.POST("/availability", AddAvailabilityHandler(mockSvc))
+
+	body, _ := json.Marshal(models.Availability{EventID: primitive.NewObjectID()})
+	req, _ := http.NewRequest(http.MethodPost, "/availability", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	mockSvc.AssertNotCalled(t, "AddAvailability", mock.Anything, mock.Anything)
+}
+
+func TestCreateAvailabilityRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for err, status := range map[error]int{
+		services.ErrInvalidSlot: http.StatusBadRequest,
+		mongo.ErrNoDocuments:    http.StatusNotFound,
+	} {
+		mockSvc := new(mocker.MockAvailabilityService)
+		mockSvc.On("AddAvailability", mock.Anything, mock.Anything).Return((*models.Availability)(nil), err)
+
+		router := gin.New()
+		router.Use(withIdentity("u1"))
+		router.POST("/availability", AddAvailabilityHandler(mockSvc))
+
+		body, _ := json.Marshal(models.Availability{EventID: primitive.NewObjectID(), SlotID: primitive.NewObjectID()})
+		req, _ := http.NewRequest(http.MethodPost, "/availability", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, status, resp.Code, err.Error())
+	}
+}
+
 // --------- DELETE /availability/:id -----------
 
 func TestDeleteAvailabilityHandler(t *testing.T) {
@@ -228,6 +484,30 @@ func TestUpdateAvailabilityHandler(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, resp.Code)
 }
 
+func TestUpdateAvailabilityHandlerReturnsStoredAnswer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockAvailabilityService)
+
+	id, eventID, slotID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	stored := &models.Availability{ID: id, EventID: eventID, SlotID: slotID, UserID: "u1", OrgID: "eng"}
+	mockSvc.On("UpdateAvailability", mock.Anything, id.Hex(), models.Availability{SlotID: slotID, UserID: "u2"}).Return(stored, nil)
+
+	router := gin.New()
+	router.PUT("/availability/:id", UpdateAvailabilityHandler(mockSvc))
+
+	body, _ := json.Marshal(models.Availability{SlotID: slotID, UserID: "u2"})
+	req, _ := http.NewRequest(http.MethodPut, "/availability/"+id.Hex(), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var got models.Availability
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &got))
+	assert.Equal(t, *stored, got)
+}
+
 // --------- GET /event/:id/availability -----------
 
 func TestGetAvailabilityByEventHandler(t *testing.T) {
@@ -237,7 +517,7 @@ func TestGetAvailabilityByEventHandler(t *testing.T) {
 	id := primitive.NewObjectID().String()
 	avail := &models.Availability{EventID: primitive.NewObjectID(), ID: primitive.NewObjectID()}
 
-	mockSvc.On("GetAvailabilitiesByEvent", mock.Anything, id).Return(avail, nil)
+	mockSvc.On("ListAvailabilitiesByEvent", mock.Anything, id, mock.Anything).Return(&query.Page[models.Availability]{Items: []models.Availability{*avail}}, nil)
 
 	router := gin.New()
 	router.GET("/event/:id/availability", GetAvailabilityByEventHandler(mockSvc))
@@ -250,6 +530,83 @@ func TestGetAvailabilityByEventHandler(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, resp.Code)
 }
 
+func TestGetAvailabilityByEventHandlerShapes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockAvailabilityService)
+
+	id := primitive.NewObjectID().Hex()
+	avail := models.Availability{ID: primitive.NewObjectID(), UserID: "u1"}
+	mockSvc.On("ListAvailabilitiesByEvent", mock.Anything, id, query.FindOptions{Limit: query.MaxPageSize, Fields: []string{"userId"}}).
+		Return(&query.Page[models.Availability]{Items: []models.Availability{avail}, Total: 1}, nil)
+	mockSvc.On("ListAvailabilitiesByEvent", mock.Anything, id, query.FindOptions{Limit: 1}).
+		Return(&query.Page[models.Availability]{Items: []models.Availability{avail}, Next: "p2", Total: 2}, nil)
+
+	router := gin.New()
+	router.GET("/event/:id/availability", GetAvailabilityByEventHandler(mockSvc))
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/event/"+id+"/availability?fields=userId", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `[{"id":"`+avail.ID.Hex()+`","userId":"u1"}]`, resp.Body.String())
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/event/"+id+"/availability?limit=1", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var page query.Page[models.Availability]
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &page))
+	assert.Equal(t, "p2", page.Next)
+	assert.Equal(t, int64(2), page.Total)
+}
+
+func TestGetAvailabilityByEventNDJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockAvailabilityService)
+
+	rows := []models.Availability{{UserID: "a"}, {UserID: "b"}}
+	seq := func(fail error) iter.Seq2[models.Availability, error] {
+		return func(yield func(models.Availability, error) bool) {
+			for _, r := range rows {
+				if !yield(r, nil) {
+					return
+				}
+			}
+			if fail != nil {
+				yield(models.Availability{}, fail)
+			}
+		}
+	}
+	mockSvc.On("StreamAvailabilitiesByEvent", mock.Anything, "ok").Return(seq(nil))
+	mockSvc.On("StreamAvailabilitiesByEvent", mock.Anything, "broken").Return(seq(errors.New("cursor died")))
+	mockSvc.On("StreamAvailabilitiesByEvent", mock.Anything, "forbidden").Return(iter.Seq2[models.Availability, error](func(yield func(models.Availability, error) bool) {
+		yield(models.Availability{}, services.ErrForbidden)
+	}))
+
+	router := gin.New()
+	router.GET("/event/:id/availability", GetAvailabilityByEventHandler(mockSvc))
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/event/ok/availability?format=ndjson", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/x-ndjson", resp.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], `"userId":"b"`)
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/event/broken/availability", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	lines = strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+	assert.Equal(t, `{"error":"cursor died"}`, lines[len(lines)-1])
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/event/forbidden/availability?format=ndjson", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
 // func TestCreateEventHandler_Success(t *testing.T) {
 // 	gin.SetMode(gin.TestMode)
 
@@ -295,3 +652,245 @@ func TestGetAvailabilityByEventHandler(t *testing.T) {
 
 // 	mockService.AssertExpectations(t)
 // }
+
+// --------- GET /events/:id/matrix -----------
+
+func TestGetAvailabilityMatrixHandlerCSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockAvailabilityService)
+
+	event := pollEvent()
+	matrix := &models.AvailabilityMatrix{
+		EventID: event.ID,
+		Slots:   []models.MatrixSlot{{TimeSlot: event.Slots[0], Total: 1}, {TimeSlot: event.Slots[1]}},
+		Rows:    []models.MatrixRow{{UserID: "u1", DisplayName: "Ann, B", Available: []bool{true, false}}},
+	}
+	mockSvc.On("GetAvailabilityMatrix", mock.Anything, "abc123").Return(matrix, nil)
+
+	router := gin.New()
+	router.GET("/events/:id/matrix", GetAvailabilityMatrixHandler(mockSvc))
+
+	req, _ := http.NewRequest(http.MethodGet, "/events/abc123/matrix?format=csv", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "userId,displayName,2025-05-06T14:00:00Z/2025-05-06T14:30:00Z,2025-05-06T16:00:00Z/2025-05-06T16:30:00Z\n"+
+		"u1,\"Ann, B\",1,0\n"+
+		"TOTAL,,1,0\n", resp.Body.String())
+}
+
+func TestProcessRecommendations(t *testing.T) {
+	event := pollEvent()
+	answers := []models.Availability{
+		{UserID: "u1", SlotID: event.Slots[1].ID},
+		{UserID: "u1", SlotID: event.Slots[1].ID}, // duplicate answer counts once
+		{UserID: "u2", SlotID: event.Slots[1].ID},
+		{UserID: "u3", SlotID: event.Slots[0].ID},
+	}
+
+	ideal, notFeasible, err := processRecommendations(*event, *services.Tally(answers))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TimeSlot{event.Slots[1]}, ideal)
+	assert.Equal(t, map[string][]string{event.Slots[1].ID.Hex(): {"u3"}}, notFeasible)
+}
+
+// --------- GET /healthz, /readyz -----------
+
+func TestHealthProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var down error
+	checker := &health.Checker{Ping: func(context.Context) error { return down }}
+	router := gin.New()
+	router.GET("/healthz", HealthzHandler())
+	router.GET("/readyz", ReadyzHandler(checker))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	assert.Equal(t, http.StatusOK, get("/healthz").Code)
+	assert.Equal(t, http.StatusOK, get("/readyz").Code)
+
+	down = errors.New("server selection timeout")
+	resp := get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Contains(t, resp.Body.String(), "server selection timeout")
+	assert.Equal(t, http.StatusOK, get("/healthz").Code)
+
+	down = nil
+	checker.Drain()
+	resp = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"draining":true`)
+}
+
+// --------- GET /events/:id/occurrences -----------
+
+func TestOccurrencesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockEventService)
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	slot := models.TimeSlot{ID: primitive.NewObjectID(), StartTime: from.Add(10 * time.Hour), EndTime: from.Add(11 * time.Hour)}
+	mockSvc.On("GetOccurrences", mock.Anything, "e1", from, from.Add(occurrenceWindow)).Return([]models.TimeSlot{slot}, nil)
+	mockSvc.On("GetOccurrences", mock.Anything, "e1", from, from).Return([]models.TimeSlot(nil), services.ErrInvalidWindow)
+
+	router := gin.New()
+	router.GET("/events/:id/occurrences", OccurrencesHandler(mockSvc))
+
+	req, _ := http.NewRequest(http.MethodGet, "/events/e1/occurrences?from=2025-05-01", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var body []models.TimeSlot
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, slot.ID, body[0].ID)
+
+	for url, code := range map[string]int{
+		"/events/e1/occurrences?from=2025-05-01&to=2025-05-01": http.StatusBadRequest,
+		"/events/e1/occurrences?to=someday":                    http.StatusBadRequest,
+		"/events/e1/occurrences?from=2025-01-01&to=2026-06-01": http.StatusBadRequest,
+	} {
+		req, _ = http.NewRequest(http.MethodGet, url, nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, code, resp.Code, url)
+	}
+}
+
+// --------- POST /events/:id/restore -----------
+
+func TestRestoreEventHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockEventService)
+	mockSvc.On("RestoreEvent", mock.Anything, "e1").Return(&models.Event{Title: "retro"}, nil)
+	mockSvc.On("RestoreEvent", mock.Anything, "e2").Return((*models.Event)(nil), mongo.ErrNoDocuments)
+
+	router := gin.New()
+	router.POST("/events/:id/restore", RestoreEventHandler(mockSvc))
+
+	req, _ := http.NewRequest(http.MethodPost, "/events/e1/restore", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "retro")
+
+	req, _ = http.NewRequest(http.MethodPost, "/events/e2/restore", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+// --------- GET /events/:id/history -----------
+
+func TestGetEventHistoryHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockEventService)
+
+	entry := models.AuditEntry{ID: primitive.NewObjectID(), Action: models.AuditDeleted, Actor: "owner",
+		Changes: []models.FieldChange{{Field: "slotId", Before: "abc"}}}
+	page := &query.Page[models.AuditEntry]{Items: []models.AuditEntry{entry}, Total: 1}
+	mockSvc.On("GetEventHistory", mock.Anything, "e1", query.FindOptions{Sort: []query.SortField{{Field: "at", Desc: true}}}).Return(page, nil)
+	mockSvc.On("GetEventHistory", mock.Anything, "e2", mock.Anything).Return((*query.Page[models.AuditEntry])(nil), services.ErrForbidden)
+
+	router := gin.New()
+	router.GET("/events/:id/history", GetEventHistoryHandler(mockSvc))
+
+	req, _ := http.NewRequest(http.MethodGet, "/events/e1/history?sort=-at", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var body query.Page[models.AuditEntry]
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, "owner", body.Items[0].Actor)
+	assert.Equal(t, "slotId", body.Items[0].Changes[0].Field)
+
+	req, _ = http.NewRequest(http.MethodGet, "/events/e2/history", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+// --------- GET /events/:id?tz= -----------
+
+func TestViewerZone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockEventSvc := new(mocker.MockEventService)
+	mockUserSvc := new(mocker.MockUserService)
+
+	start := time.Date(2025, 5, 6, 14, 0, 0, 0, time.UTC)
+	event := &models.Event{ID: primitive.NewObjectID(), TimeZone: "Europe/Berlin",
+		Slots: []models.TimeSlot{{ID: primitive.NewObjectID(), StartTime: start, EndTime: start.Add(time.Hour)}}}
+	stored := event.Slots
+	mockEventSvc.On("GetEvent", mock.Anything, "e1").Return(event, nil)
+	mockUserSvc.On("GetProfile", mock.Anything, "tokyo").Return(&models.User{UserID: "tokyo", TimeZone: "Asia/Tokyo"}, nil)
+	mockUserSvc.On("GetProfile", mock.Anything, "nozone").Return(&models.User{UserID: "nozone"}, nil)
+
+	for _, tc := range []struct {
+		user, url, want string
+	}{
+		{"tokyo", "/events/e1?tz=America/New_York", `"startTime":"2025-05-06T10:00:00-04:00"`},
+		{"tokyo", "/events/e1", `"startTime":"2025-05-06T23:00:00+09:00"`},
+		{"nozone", "/events/e1", `"startTime":"2025-05-06T16:00:00+02:00"`}, // the event's zone
+	} {
+		router := gin.New()
+		router.Use(withIdentity(tc.user), ViewerZone(mockUserSvc))
+		router.GET("/events/:id", GetEventHandler(mockEventSvc))
+
+		req, _ := http.NewRequest(http.MethodGet, tc.url, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code, tc.url)
+		assert.Contains(t, resp.Body.String(), tc.want, tc.url)
+	}
+	assert.Equal(t, time.UTC, stored[0].StartTime.Location(), "slots shared with the cache are not written to")
+	mockUserSvc.AssertNumberOfCalls(t, "GetProfile", 2) // not with ?tz=
+
+	router := gin.New()
+	router.Use(withIdentity("tokyo"), ViewerZone(mockUserSvc))
+	router.GET("/events/:id", GetEventHandler(mockEventSvc))
+	req, _ := http.NewRequest(http.MethodGet, "/events/e1?tz=Moon/Tranquility", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+// --------- GET, PUT /users/:id/profile -----------
+
+func TestProfileHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockUserSvc := new(mocker.MockUserService)
+
+	saved := &models.User{UserID: "u1", TimeZone: "Africa/Lagos", WorkStart: "08:00", WorkEnd: "16:00"}
+	mockUserSvc.On("UpdateProfile", mock.Anything, "u1", mock.MatchedBy(func(u models.User) bool { return u.TimeZone == "Africa/Lagos" })).Return(saved, nil)
+	mockUserSvc.On("UpdateProfile", mock.Anything, "u1", mock.Anything).Return((*models.User)(nil), services.ErrInvalidTimeZone)
+	mockUserSvc.On("GetProfile", mock.Anything, "u2").Return(&models.User{UserID: "u2", WorkStart: "09:00", WorkEnd: "17:00"}, nil)
+
+	router := gin.New()
+	router.Use(withIdentity("u1"))
+	router.GET("/users/:id/profile", GetProfileHandler(mockUserSvc))
+	router.PUT("/users/:id/profile", UpdateProfileHandler(mockUserSvc))
+
+	req, _ := http.NewRequest(http.MethodPut, "/users/me/profile", strings.NewReader(`{"timeZone":"Africa/Lagos","workStart":"08:00","workEnd":"16:00"}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"timeZone":"Africa/Lagos"`)
+
+	req, _ = http.NewRequest(http.MethodPut, "/users/u1/profile", strings.NewReader(`{"timeZone":"Lagos"}`))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	req, _ = http.NewRequest(http.MethodGet, "/users/u2/profile", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"workStart":"09:00"`)
+}
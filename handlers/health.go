@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/chetanugale/scheduling-system/health"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// HealthzHandler is the liveness probe: the process is up and serving.
+func HealthzHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, bson.M{"status": "ok"})
+	}
+}
+
+// ReadyzHandler is the readiness probe: 503 while the database is unreachable
+// or the server is draining, with the full report either way.
+func ReadyzHandler(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := checker.Check(c.Request.Context())
+		status := http.StatusOK
+		if !r.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, r)
+	}
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/ical"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+func eventUID(id primitive.ObjectID) string {
+	return id.Hex() + "@scheduling-system"
+}
+
+// finalSlot returns the slot a finalized event was fixed to.
+func finalSlot(event models.Event) (models.TimeSlot, bool) {
+	if event.Status != models.EventStatusFinalized || event.FinalSlotID == nil {
+		return models.TimeSlot{}, false
+	}
+	i := slices.IndexFunc(event.Slots, func(t models.TimeSlot) bool { return t.ID == *event.FinalSlotID })
+	if i < 0 {
+		return models.TimeSlot{}, false
+	}
+	return event.Slots[i], true
+}
+
+// calendarEvent converts a finalized event to a VEVENT, repeating if the event
+// does. Everybody who answered the poll is an attendee, accepted if they were
+// available for the chosen slot.
+func calendarEvent(event models.Event, slot models.TimeSlot, answers []models.Availability, now time.Time) ical.Event {
+	ve := ical.Event{
+		UID:     eventUID(event.ID),
+		Summary: event.Title,
+		Start:   slot.StartTime,
+		End:     slot.EndTime,
+		Stamp:   now,
+	}
+	if event.Recurrence != nil {
+		ve.RRule, ve.ExDates = event.Recurrence.RRule, event.Recurrence.ExDates
+		ve.TimeZone = event.TimeZone // the rule repeats in it, see services.Occurrences
+	}
+	if len(event.Organizers) > 0 {
+		ve.Organizer = &ical.Attendee{UserID: event.Organizers[0]}
+	}
+	accepted := map[string]bool{}
+	var users []string
+	names := map[string]string{}
+	for _, a := range answers {
+		if _, seen := names[a.UserID]; !seen {
+			users = append(users, a.UserID)
+		}
+		names[a.UserID] = a.DisplayName
+		if a.SlotID == slot.ID {
+			accepted[a.UserID] = true
+		}
+	}
+	for _, u := range users {
+		status := ical.PartStatDeclined
+		if accepted[u] {
+			status = ical.PartStatAccepted
+		}
+		ve.Attendees = append(ve.Attendees, ical.Attendee{UserID: u, Name: names[u], PartStat: status})
+	}
+	return ve
+}
+
+// EventICSHandler exports a finalized event as an RFC 5545 calendar.
+func EventICSHandler(svcEvent services.EventService, svcAvail services.AvailabilityService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		eventId := c.Param("id")
+		event, err := svcEvent.GetEvent(c, eventId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, bson.M{"error": "Event not found"})
+			return
+		}
+		slot, ok := finalSlot(*event)
+		if !ok {
+			c.JSON(http.StatusConflict, bson.M{"error": services.ErrNotFinalized.Error()})
+			return
+		}
+		answers, err := svcAvail.GetAvailabilitiesByEvent(c, eventId)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.ics"`, event.ID.Hex()))
+		writeCalendar(c, event.Title, []ical.Event{calendarEvent(*event, slot, answers, time.Now())})
+	}
+}
+
+// writeCalendar renders the calendar before sending anything, so a failure
+// can still be answered with a 500 rather than a truncated file.
+func writeCalendar(c *gin.Context, name string, events []ical.Event) {
+	var buf bytes.Buffer
+	if err := ical.WriteCalendar(&buf, name, events); err != nil {
+		c.JSON(http.StatusInternalServerError, bson.M{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, calendarContentType, buf.Bytes())
+}
+
+// UserCalendarHandler serves a subscribable feed of every finalized event the
+// user is available for.
+func UserCalendarHandler(svcEvent services.EventService, svcAvail services.AvailabilityService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userCalendar(c, c, c.Param("id"), svcEvent, svcAvail)
+	}
+}
+
+// FeedCalendarHandler serves the same feed to calendar clients, which cannot
+// send API credentials: the secret feed token in the URL stands for the user.
+func FeedCalendarHandler(svcUser services.UserService, svcEvent services.EventService, svcAvail services.AvailabilityService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, userId, err := svcUser.OpenFeed(c, c.Param("token"))
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
+		}
+		userCalendar(ctx, c, userId, svcEvent, svcAvail)
+	}
+}
+
+// RotateFeedTokenHandler issues a new feed URL for the caller. The previous
+// one stops working.
+func RotateFeedTokenHandler(svc services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := svc.RotateFeedToken(c, userParam(c))
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"feedToken": token, "url": "/feeds/" + token + "/calendar.ics"})
+	}
+}
+
+func RevokeFeedTokenHandler(svc services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := svc.RevokeFeedToken(c, userParam(c)); err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// userCalendar writes the feed of userId, reading as ctx.
+func userCalendar(ctx context.Context, c *gin.Context, userId string, svcEvent services.EventService, svcAvail services.AvailabilityService) {
+	answers, err := svcAvail.GetAvailabilitiesByUser(ctx, userId)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	seen := map[primitive.ObjectID]bool{}
+	events := []ical.Event{}
+	for _, a := range answers {
+		if seen[a.EventID] {
+			continue
+		}
+		seen[a.EventID] = true
+		event, err := svcEvent.GetEvent(ctx, a.EventID.Hex())
+		if err != nil {
+			continue // event deleted since the user answered
+		}
+		slot, ok := finalSlot(*event)
+		if !ok {
+			continue
+		}
+		attending := slices.ContainsFunc(answers, func(o models.Availability) bool {
+			return o.EventID == event.ID && o.SlotID == slot.ID
+		})
+		if !attending {
+			continue
+		}
+		mine := []models.Availability{{UserID: userId, SlotID: slot.ID, DisplayName: a.DisplayName}}
+		events = append(events, calendarEvent(*event, slot, mine, now))
+	}
+	writeCalendar(c, "Scheduled events for "+userId, events)
+}
+
+// maxCalendarUpload bounds the size of an imported .ics file.
+const maxCalendarUpload = 1 << 20
+
+// readCalendar parses an iCalendar payload sent either as the "file" field of a
+// multipart form or as the raw request body. Floating times are read in
+// floating.
+func readCalendar(c *gin.Context, floating *time.Location) (*ical.Calendar, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCalendarUpload)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ical.Parse(f, floating)
+	}
+	return ical.Parse(c.Request.Body, floating)
+}
+
+// ImportEventHandler creates an event whose candidate slots come from an uploaded
+// calendar. "title" and "estimatedMins" may be given as query or form fields.
+// Floating times are in the importer's zone, UTC if they have none.
+func ImportEventHandler(svc services.EventService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cal, err := readCalendar(c, viewerZone(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		title := c.DefaultPostForm("title", c.Query("title"))
+		mins, _ := strconv.Atoi(c.DefaultPostForm("estimatedMins", c.Query("estimatedMins")))
+		e, err := services.EventFromCalendar(cal, title, mins)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusBadRequest), bson.M{"error": err.Error()})
+			return
+		}
+		created, err := svc.CreateEvent(c, e)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, created)
+	}
+}
+
+// ImportAvailabilityHandler answers an event's poll for the caller from their
+// calendar's free/busy blocks, replacing previous answers.
+func ImportAvailabilityHandler(svcEvent services.EventService, svcAvail services.AvailabilityService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		event, err := svcEvent.GetEvent(c, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, bson.M{"error": "Event not found"})
+			return
+		}
+		if event.Status == models.EventStatusFinalized {
+			c.JSON(http.StatusConflict, bson.M{"error": services.ErrPollClosed.Error()})
+			return
+		}
+		cal, err := readCalendar(c, zoneFor(c, *event)) // the importer's zone, else the event's
+		if err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		answers, err := services.SetAnswers(c, svcAvail, *event, services.AvailableSlotIDs(*event, cal))
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, answers)
+	}
+}
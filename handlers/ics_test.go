@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/mocker"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --------- GET /events/:id/ics -----------
+
+func TestEventICSHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	event := pollEvent()
+	event.Status = models.EventStatusFinalized
+	event.FinalSlotID = &event.Slots[1].ID
+
+	mockEventSvc := new(mocker.MockEventService)
+	mockAvailSvc := new(mocker.MockAvailabilityService)
+	mockEventSvc.On("GetEvent", mock.Anything, "abc123").Return(event, nil)
+	mockAvailSvc.On("GetAvailabilitiesByEvent", mock.Anything, "abc123").Return([]models.Availability{
+		{UserID: "u1", SlotID: event.Slots[1].ID},
+		{UserID: "u2", SlotID: event.Slots[0].ID},
+	}, nil)
+
+	router := gin.New()
+	router.GET("/events/:id/ics", EventICSHandler(mockEventSvc, mockAvailSvc))
+
+	req, _ := http.NewRequest(http.MethodGet, "/events/abc123/ics", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, calendarContentType, resp.Header().Get("Content-Type"))
+	body := strings.ReplaceAll(resp.Body.String(), "\r\n ", "")
+	assert.Contains(t, body, "UID:"+event.ID.Hex()+"@scheduling-system\r\n")
+	assert.Contains(t, body, "DTSTART:20250506T160000Z\r\n")
+	assert.Contains(t, body, "ORGANIZER:urn:x-scheduling-system:user:owner\r\n")
+	assert.Contains(t, body, "PARTSTAT=ACCEPTED:urn:x-scheduling-system:user:u1\r\n")
+	assert.Contains(t, body, "PARTSTAT=DECLINED:urn:x-scheduling-system:user:u2\r\n")
+	assert.NotContains(t, body, "RRULE")
+
+	event.Recurrence = &models.Recurrence{RRule: "FREQ=WEEKLY;COUNT=10"}
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Contains(t, resp.Body.String(), "RRULE:FREQ=WEEKLY;COUNT=10\r\n")
+}
+
+func TestEventICSHandlerNotFinalized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockEventSvc := new(mocker.MockEventService)
+	mockAvailSvc := new(mocker.MockAvailabilityService)
+	mockEventSvc.On("GetEvent", mock.Anything, "abc123").Return(pollEvent(), nil)
+
+	router := gin.New()
+	router.GET("/events/:id/ics", EventICSHandler(mockEventSvc, mockAvailSvc))
+
+	req, _ := http.NewRequest(http.MethodGet, "/events/abc123/ics", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+}
+
+// --------- GET /users/:id/calendar.ics -----------
+
+func TestUserCalendarHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	attending := pollEvent()
+	attending.Status = models.EventStatusFinalized
+	attending.FinalSlotID = &attending.Slots[0].ID
+	missed := pollEvent()
+	missed.Status = models.EventStatusFinalized
+	missed.FinalSlotID = &missed.Slots[0].ID
+	open := pollEvent()
+
+	mockEventSvc := new(mocker.MockEventService)
+	mockAvailSvc := new(mocker.MockAvailabilityService)
+	for _, e := range []*models.Event{attending, missed, open} {
+		mockEventSvc.On("GetEvent", mock.Anything, e.ID.Hex()).Return(e, nil)
+	}
+	mockAvailSvc.On("GetAvailabilitiesByUser", mock.Anything, "u1").Return([]models.Availability{
+		{ID: primitive.NewObjectID(), UserID: "u1", EventID: attending.ID, SlotID: attending.Slots[1].ID},
+		{ID: primitive.NewObjectID(), UserID: "u1", EventID: attending.ID, SlotID: attending.Slots[0].ID},
+		{ID: primitive.NewObjectID(), UserID: "u1", EventID: missed.ID, SlotID: missed.Slots[1].ID},
+		{ID: primitive.NewObjectID(), UserID: "u1", EventID: open.ID, SlotID: open.Slots[0].ID},
+	}, nil)
+
+	router := gin.New()
+	router.GET("/users/:id/calendar.ics", UserCalendarHandler(mockEventSvc, mockAvailSvc))
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/u1/calendar.ics", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	body := resp.Body.String()
+	assert.Equal(t, 1, strings.Count(body, "BEGIN:VEVENT"))
+	assert.Contains(t, body, "UID:"+attending.ID.Hex())
+	mockEventSvc.AssertNumberOfCalls(t, "GetEvent", 3)
+}
+
+// --------- GET /feeds/:token/calendar.ics -----------
+
+func TestFeedCalendarHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockUserSvc := new(mocker.MockUserService)
+	mockEventSvc := new(mocker.MockEventService)
+	mockAvailSvc := new(mocker.MockAvailabilityService)
+
+	type key struct{}
+	feedCtx := context.WithValue(context.Background(), key{}, "u1")
+	mockUserSvc.On("OpenFeed", mock.Anything, "secret").Return(feedCtx, "u1", nil)
+	mockUserSvc.On("OpenFeed", mock.Anything, "revoked").Return(nil, "", services.ErrInvalidFeedToken)
+	// the answers are read as the feed's user, not as the anonymous request
+	mockAvailSvc.On("GetAvailabilitiesByUser", feedCtx, "u1").Return([]models.Availability{}, nil)
+
+	router := gin.New()
+	router.GET("/feeds/:token/calendar.ics", FeedCalendarHandler(mockUserSvc, mockEventSvc, mockAvailSvc))
+
+	for path, status := range map[string]int{
+		"/feeds/secret/calendar.ics":  http.StatusOK,
+		"/feeds/revoked/calendar.ics": http.StatusForbidden,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, status, resp.Code, path)
+	}
+	mockAvailSvc.AssertExpectations(t)
+}
+
+// --------- POST /users/:id/feed-token -----------
+
+func TestRotateFeedTokenHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockUserSvc := new(mocker.MockUserService)
+	mockUserSvc.On("RotateFeedToken", mock.Anything, "u1").Return("secret", nil)
+
+	router := gin.New()
+	router.Use(withIdentity("u1"))
+	router.POST("/users/:id/feed-token", RotateFeedTokenHandler(mockUserSvc))
+
+	req, _ := http.NewRequest(http.MethodPost, "/users/me/feed-token", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"feedToken":"secret","url":"/feeds/secret/calendar.ics"}`, resp.Body.String())
+}
+
+// --------- POST /events/import -----------
+
+func TestImportEventHandlerTooManySlots(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mocker.MockEventService)
+
+	var ics strings.Builder
+	ics.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\n")
+	start := time.Date(2025, 5, 6, 9, 0, 0, 0, time.UTC)
+	for i := range services.MaxImportedSlots + 1 {
+		t := start.Add(time.Duration(i) * time.Hour)
+		fmt.Fprintf(&ics, "BEGIN:VEVENT\r\nDTSTART:%s\r\nDTEND:%s\r\nEND:VEVENT\r\n", t.Format("20060102T150405Z"), t.Add(time.Hour).Format("20060102T150405Z"))
+	}
+	ics.WriteString("END:VCALENDAR\r\n")
+
+	router := gin.New()
+	router.POST("/events/import", ImportEventHandler(mockSvc))
+
+	req, _ := http.NewRequest(http.MethodPost, "/events/import?title=Retro", strings.NewReader(ics.String()))
+	req.Header.Set("Content-Type", "text/calendar")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "more than 500")
+	mockSvc.AssertNotCalled(t, "CreateEvent", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// wantsFormat reports whether the client asked for format with ?format= or
+// for its media type in the Accept header.
+func wantsFormat(c *gin.Context, format, mediaType string) bool {
+	if f := c.Query("format"); f != "" {
+		return strings.EqualFold(f, format)
+	}
+	return strings.Contains(c.GetHeader("Accept"), mediaType)
+}
+
+// writeMatrixCSV writes one row per user with 1/0 cells, followed by a totals row.
+func writeMatrixCSV(c *gin.Context, m *models.AvailabilityMatrix) error {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-matrix.csv"`, m.EventID.Hex()))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	header := []string{"userId", "displayName"}
+	totals := []string{"TOTAL", ""}
+	for _, s := range m.Slots {
+		header = append(header, s.StartTime.UTC().Format(time.RFC3339)+"/"+s.EndTime.UTC().Format(time.RFC3339))
+		totals = append(totals, strconv.Itoa(s.Total))
+	}
+	w.Write(header)
+	for _, r := range m.Rows {
+		record := []string{r.UserID, r.DisplayName}
+		for _, ok := range r.Available {
+			if ok {
+				record = append(record, "1")
+			} else {
+				record = append(record, "0")
+			}
+		}
+		w.Write(record)
+	}
+	w.Write(totals)
+	w.Flush()
+	return w.Error()
+}
+
+// GetAvailabilityMatrixHandler returns the users x slots grid of an event as
+// JSON, or CSV with ?format=csv.
+func GetAvailabilityMatrixHandler(svc services.AvailabilityService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		matrix, err := svc.GetAvailabilityMatrix(c, c.Param("id"))
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusNotFound), bson.M{"error": err.Error()})
+			return
+		}
+		if wantsFormat(c, "csv", "text/csv") {
+			writeMatrixCSV(c, matrix)
+			return
+		}
+		if loc := viewerZone(c); loc != nil {
+			for i := range matrix.Slots {
+				s := &matrix.Slots[i]
+				s.StartTime, s.EndTime = s.StartTime.In(loc), s.EndTime.In(loc)
+			}
+		}
+		c.JSON(http.StatusOK, matrix)
+	}
+}
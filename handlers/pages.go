@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// slotLayout is how slot times are shown on HTML pages.
+const slotLayout = "Mon 2 Jan 15:04"
+
+// Templates returns the HTML templates used by the page handlers, for gin's
+// Engine.SetHTMLTemplate.
+func Templates() *template.Template {
+	return template.Must(template.New("").ParseFS(templateFS, "templates/*.tmpl"))
+}
+
+type slotColumn struct {
+	ID          string
+	Label       string
+	Total       int
+	Recommended bool
+	Mine        bool
+}
+
+type resultCell struct {
+	Available   bool
+	Recommended bool
+}
+
+type resultRow struct {
+	User  string
+	Cells []resultCell
+}
+
+type pollPage struct {
+	Event       models.Event
+	Viewer      string
+	Slots       []slotColumn
+	Rows        []resultRow // every participant, only on the results page
+	ShowResults bool
+	Form        bool // the viewer's own row of checkboxes, only on the poll page
+	Finalized   bool
+	Zone        string // of the slot labels
+	CSRF        string // echoed back by the form, see validCSRF
+}
+
+// buildPollPage lays out the slots as a row of checkboxes for viewer, checked
+// where they answered. Other participants are not shown on the poll page.
+func buildPollPage(event models.Event, answers []models.Availability, viewer auth.Identity) pollPage {
+	page := pollPage{
+		Event:     event,
+		Viewer:    viewer.UserID,
+		Form:      true,
+		Finalized: event.Status == models.EventStatusFinalized,
+	}
+	if viewer.Name != "" {
+		page.Viewer = viewer.Name
+	}
+	mine := map[primitive.ObjectID]bool{}
+	for _, a := range answers {
+		if a.UserID == viewer.UserID {
+			mine[a.SlotID] = true
+		}
+	}
+	final := map[primitive.ObjectID]bool{}
+	if event.FinalSlotID != nil {
+		final[*event.FinalSlotID] = true
+	}
+	for _, slot := range event.Slots {
+		page.Slots = append(page.Slots, slotColumn{
+			ID:          slot.ID.Hex(),
+			Label:       slotLabel(slot),
+			Recommended: final[slot.ID],
+			Mine:        mine[slot.ID],
+		})
+	}
+	return page
+}
+
+// buildResultsPage pivots the answer matrix into a participants x slots grid
+// with per-slot totals. The final slot, or before finalizing the recommended
+// ones, are highlighted.
+func buildResultsPage(event models.Event, matrix models.AvailabilityMatrix, tallies models.SlotTallies) pollPage {
+	page := pollPage{
+		Event:       event,
+		ShowResults: true,
+		Finalized:   event.Status == models.EventStatusFinalized,
+	}
+	recommended := map[primitive.ObjectID]bool{}
+	if event.FinalSlotID != nil {
+		recommended[*event.FinalSlotID] = true
+	} else if len(tallies.Users) > 0 {
+		idealSlots, _, _ := processRecommendations(event, tallies)
+		for _, slot := range idealSlots {
+			recommended[slot.ID] = true
+		}
+	}
+
+	// the matrix columns follow the event as the service read it
+	column := map[primitive.ObjectID]int{}
+	for i, slot := range matrix.Slots {
+		column[slot.ID] = i
+	}
+	for _, slot := range event.Slots {
+		col := slotColumn{ID: slot.ID.Hex(), Label: slotLabel(slot), Recommended: recommended[slot.ID]}
+		if i, ok := column[slot.ID]; ok {
+			col.Total = matrix.Slots[i].Total
+		}
+		page.Slots = append(page.Slots, col)
+	}
+	for _, r := range matrix.Rows {
+		row := resultRow{User: r.UserID}
+		if r.DisplayName != "" {
+			row.User = r.DisplayName
+		}
+		for _, slot := range event.Slots {
+			i, ok := column[slot.ID]
+			row.Cells = append(row.Cells, resultCell{Available: ok && r.Available[i], Recommended: recommended[slot.ID]})
+		}
+		page.Rows = append(page.Rows, row)
+	}
+	return page
+}
+
+func slotLabel(slot models.TimeSlot) string {
+	return slot.StartTime.Format(slotLayout) + " - " + slot.EndTime.Format("15:04")
+}
+
+// Cookies of the poll page, scoped to the path of one poll.
+const (
+	editCookie = "poll_edit" // the guest's edit token, set on their first answer
+	csrfCookie = "poll_csrf" // double submit token, repeated in the form's csrf field
+	cookieAge  = 180 * 24 * 60 * 60
+)
+
+// setPollCookie stores an HttpOnly cookie valid below the poll's own path only.
+func setPollCookie(c *gin.Context, name, value string, sameSite http.SameSite) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/polls/" + c.Param("token"),
+		MaxAge:   cookieAge,
+		Secure:   c.Request.TLS != nil,
+		HttpOnly: true,
+		SameSite: sameSite,
+	})
+}
+
+// csrfToken returns the page's CSRF token, issuing a new one on the first visit.
+func csrfToken(c *gin.Context) (string, error) {
+	if token, err := c.Cookie(csrfCookie); err == nil && token != "" {
+		return token, nil
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	setPollCookie(c, csrfCookie, token, http.SameSiteStrictMode)
+	return token, nil
+}
+
+// validCSRF reports whether the form repeats the token of the CSRF cookie.
+// A cross-site form can make the browser send the cookie but cannot read it.
+func validCSRF(c *gin.Context) bool {
+	token, err := c.Cookie(csrfCookie)
+	if err != nil || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(c.PostForm("csrf"))) == 1
+}
+
+// PollPageHandler renders a shared poll as a checkbox grid. Browsers cannot
+// send API credentials, so the page lives on the share link: a guest who
+// already answered is recognized by the edit token cookie, a new one is
+// asked for their name.
+func PollPageHandler(svc services.GuestService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		event, err := svc.GetSharedEvent(c, c.Param("token"))
+		if err != nil {
+			c.String(errorStatus(err, http.StatusInternalServerError), err.Error())
+			return
+		}
+		var viewer auth.Identity
+		var answers []models.Availability
+		if editToken, err := c.Cookie(editCookie); err == nil {
+			resp, err := svc.GetResponse(c, c.Param("token"), editToken)
+			switch {
+			case err == nil:
+				viewer = auth.Identity{UserID: resp.ParticipantID, Name: resp.DisplayName}
+				answers = resp.Availability
+			case !errors.Is(err, services.ErrInvalidEditToken):
+				c.String(errorStatus(err, http.StatusInternalServerError), err.Error())
+				return
+			}
+		}
+		token, err := csrfToken(c)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		localize(c, event)
+		page := buildPollPage(*event, answers, viewer)
+		page.Zone = zoneFor(c, *event).String()
+		page.CSRF = token
+		c.HTML(http.StatusOK, "poll.tmpl", page)
+	}
+}
+
+// SubmitPollPageHandler saves the checked slots as the guest's answers and
+// redirects back to the page. The first post creates the guest from the name
+// field and keeps their edit token in a cookie.
+func SubmitPollPageHandler(svc services.GuestService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !validCSRF(c) {
+			c.String(http.StatusForbidden, "invalid CSRF token, reload the page")
+			return
+		}
+		if editToken, err := c.Cookie(editCookie); err == nil {
+			if _, err := svc.UpdateResponse(c, c.Param("token"), editToken, c.PostFormArray("slotId")); err != nil {
+				c.String(errorStatus(err, http.StatusInternalServerError), err.Error())
+				return
+			}
+		} else {
+			resp, err := svc.Respond(c, c.Param("token"), c.PostForm("name"), c.PostFormArray("slotId"))
+			if err != nil {
+				c.String(errorStatus(err, http.StatusInternalServerError), err.Error())
+				return
+			}
+			setPollCookie(c, editCookie, resp.EditToken, http.SameSiteLaxMode)
+		}
+		c.Redirect(http.StatusSeeOther, c.Request.URL.RequestURI())
+	}
+}
+
+// ResultsPageHandler renders the answers of every participant with the totals
+// and the recommended slots, for the organizers of the event. Participants
+// answer on the share link page.
+func ResultsPageHandler(svcEvent services.EventService, svcAvail services.AvailabilityService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		event, err := svcEvent.GetEvent(c, c.Param("id"))
+		if err != nil {
+			c.String(errorStatus(err, http.StatusNotFound), err.Error())
+			return
+		}
+		matrix, err := svcAvail.GetAvailabilityMatrix(c, c.Param("id"))
+		if err != nil {
+			c.String(errorStatus(err, http.StatusInternalServerError), err.Error())
+			return
+		}
+		tallies, err := svcAvail.TallySlots(c, c.Param("id"))
+		if err != nil {
+			c.String(errorStatus(err, http.StatusInternalServerError), err.Error())
+			return
+		}
+		localize(c, event)
+		page := buildResultsPage(*event, *matrix, *tallies)
+		page.Zone = zoneFor(c, *event).String()
+		c.HTML(http.StatusOK, "poll.tmpl", page)
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/mocker"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func pollEvent() *models.Event {
+	start := time.Date(2025, 5, 6, 14, 0, 0, 0, time.UTC)
+	return &models.Event{
+		ID:         primitive.NewObjectID(),
+		Title:      "Retro",
+		Organizers: []string{"owner"},
+		Slots: []models.TimeSlot{
+			{ID: primitive.NewObjectID(), StartTime: start, EndTime: start.Add(30 * time.Minute)},
+			{ID: primitive.NewObjectID(), StartTime: start.Add(2 * time.Hour), EndTime: start.Add(150 * time.Minute)},
+		},
+	}
+}
+
+// mockGuestService stands in for services.GuestService. It lives here rather
+// than in mocker, which services' own tests import.
+type mockGuestService struct {
+	mock.Mock
+}
+
+func (m *mockGuestService) GetSharedEvent(ctx context.Context, shareToken string) (*models.Event, error) {
+	args := m.Called(ctx, shareToken)
+	return args.Get(0).(*models.Event), args.Error(1)
+}
+func (m *mockGuestService) Respond(ctx context.Context, shareToken string, name string, slotIDs []string) (*services.GuestResponse, error) {
+	args := m.Called(ctx, shareToken, name, slotIDs)
+	return args.Get(0).(*services.GuestResponse), args.Error(1)
+}
+func (m *mockGuestService) GetResponse(ctx context.Context, shareToken string, editToken string) (*services.GuestResponse, error) {
+	args := m.Called(ctx, shareToken, editToken)
+	return args.Get(0).(*services.GuestResponse), args.Error(1)
+}
+func (m *mockGuestService) UpdateResponse(ctx context.Context, shareToken string, editToken string, slotIDs []string) (*services.GuestResponse, error) {
+	args := m.Called(ctx, shareToken, editToken, slotIDs)
+	return args.Get(0).(*services.GuestResponse), args.Error(1)
+}
+
+func pollRouter(svc services.GuestService) *gin.Engine {
+	router := gin.New()
+	router.SetHTMLTemplate(Templates())
+	router.GET("/polls/:token/page", PollPageHandler(svc))
+	router.POST("/polls/:token/page", SubmitPollPageHandler(svc))
+	return router
+}
+
+// --------- GET /events/:id/page -----------
+
+func resultsRouter(svcEvent services.EventService, svcAvail services.AvailabilityService, userID string) *gin.Engine {
+	router := gin.New()
+	router.SetHTMLTemplate(Templates())
+	router.Use(withIdentity(userID))
+	router.GET("/events/:id/page", ResultsPageHandler(svcEvent, svcAvail))
+	return router
+}
+
+func TestResultsPageHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	event := pollEvent()
+	id := event.ID.Hex()
+	matrix := &models.AvailabilityMatrix{
+		EventID: event.ID,
+		Slots:   []models.MatrixSlot{{TimeSlot: event.Slots[0], Total: 1}, {TimeSlot: event.Slots[1], Total: 2}},
+		Rows: []models.MatrixRow{
+			{UserID: "u1", Available: []bool{false, true}},
+			{UserID: "guest:1", DisplayName: "Partner", Available: []bool{true, true}},
+		},
+	}
+	tallies := &models.SlotTallies{
+		Slots: []models.SlotTally{{SlotID: event.Slots[0].ID, Users: []string{"guest:1"}, Count: 1}, {SlotID: event.Slots[1].ID, Users: []string{"guest:1", "u1"}, Count: 2}},
+		Users: []string{"guest:1", "u1"},
+	}
+	svcEvent := new(mocker.MockEventService)
+	svcEvent.On("GetEvent", mock.Anything, id).Return(event, nil)
+	svcAvail := new(mocker.MockAvailabilityService)
+	svcAvail.On("GetAvailabilityMatrix", mock.Anything, id).Return(matrix, nil)
+	svcAvail.On("TallySlots", mock.Anything, id).Return(tallies, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/events/"+id+"/page", nil)
+	resp := httptest.NewRecorder()
+
+	resultsRouter(svcEvent, svcAvail, "owner").ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	body := resp.Body.String()
+	assert.Contains(t, body, `<th class="user">Partner</th>`)
+	assert.Contains(t, body, `<th class="user">u1</th>`)
+	assert.Contains(t, body, `<th class="recommended">Tue 6 May 16:00 - 16:30</th>`)
+	assert.Contains(t, body, `<th>Tue 6 May 14:00 - 14:30</th>`)
+	assert.Contains(t, body, `<td class="recommended">2</td>`)
+	assert.Contains(t, body, "recommended slots")
+	assert.NotContains(t, body, "<form")
+}
+
+func TestResultsPageHandlerForbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	event := pollEvent()
+	id := event.ID.Hex()
+	svcEvent := new(mocker.MockEventService)
+	svcEvent.On("GetEvent", mock.Anything, id).Return(event, nil)
+	svcAvail := new(mocker.MockAvailabilityService)
+	svcAvail.On("GetAvailabilityMatrix", mock.Anything, id).Return((*models.AvailabilityMatrix)(nil), services.ErrForbidden)
+
+	req, _ := http.NewRequest(http.MethodGet, "/events/"+id+"/page", nil)
+	resp := httptest.NewRecorder()
+
+	resultsRouter(svcEvent, svcAvail, "u1").ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	svcAvail.AssertNotCalled(t, "TallySlots", mock.Anything, mock.Anything)
+}
+
+// --------- GET /polls/:token/page -----------
+
+func TestPollPageHandlerNewGuest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	event := pollEvent()
+	svc := new(mockGuestService)
+	svc.On("GetSharedEvent", mock.Anything, "tok").Return(event, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/polls/tok/page", nil)
+	resp := httptest.NewRecorder()
+
+	pollRouter(svc).ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	body := resp.Body.String()
+	assert.Contains(t, body, `name="name"`)
+	assert.Contains(t, body, "Tue 6 May 14:00 - 14:30")
+	assert.NotContains(t, body, "recommended slots")
+	cookies := resp.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, csrfCookie, cookies[0].Name)
+		assert.Equal(t, "/polls/tok", cookies[0].Path)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Contains(t, body, `name="csrf" value="`+cookies[0].Value+`"`)
+	}
+	svc.AssertNotCalled(t, "GetResponse", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPollPageHandlerReturningGuest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	event := pollEvent()
+	svc := new(mockGuestService)
+	svc.On("GetSharedEvent", mock.Anything, "tok").Return(event, nil)
+	svc.On("GetResponse", mock.Anything, "tok", "edit").Return(&services.GuestResponse{
+		ParticipantID: "guest:1",
+		DisplayName:   "Jane",
+		Availability:  []models.Availability{{UserID: "guest:1", SlotID: event.Slots[1].ID}},
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/polls/tok/page", nil)
+	req.AddCookie(&http.Cookie{Name: editCookie, Value: "edit"})
+	req.AddCookie(&http.Cookie{Name: csrfCookie, Value: "c1"})
+	resp := httptest.NewRecorder()
+
+	pollRouter(svc).ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	body := resp.Body.String()
+	assert.Contains(t, body, "Jane (you)")
+	assert.Contains(t, body, `value="`+event.Slots[1].ID.Hex()+`" checked`)
+	assert.Contains(t, body, `name="csrf" value="c1"`)
+	assert.Empty(t, resp.Result().Cookies())
+}
+
+// --------- POST /polls/:token/page -----------
+
+func TestSubmitPollPageHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	event := pollEvent()
+	slots := []string{event.Slots[0].ID.Hex()}
+	svc := new(mockGuestService)
+	svc.On("Respond", mock.Anything, "tok", "Jane", slots).Return(&services.GuestResponse{EditToken: "edit"}, nil).Once()
+	svc.On("UpdateResponse", mock.Anything, "tok", "edit", slots).Return(&services.GuestResponse{}, nil).Once()
+
+	post := func(form url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/polls/tok/page?tz=Asia/Tokyo", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		resp := httptest.NewRecorder()
+		pollRouter(svc).ServeHTTP(resp, req)
+		return resp
+	}
+	csrf := &http.Cookie{Name: csrfCookie, Value: "c1"}
+
+	// a cross-site form has the cookie sent along but cannot know its value
+	resp := post(url.Values{"name": {"Jane"}, "slotId": slots}, csrf)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	resp = post(url.Values{"name": {"Jane"}, "slotId": slots, "csrf": {"c1"}})
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	resp = post(url.Values{"name": {"Jane"}, "slotId": slots, "csrf": {"c1"}}, csrf)
+	assert.Equal(t, http.StatusSeeOther, resp.Code)
+	assert.Equal(t, "/polls/tok/page?tz=Asia/Tokyo", resp.Header().Get("Location"))
+	cookies := resp.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, editCookie, cookies[0].Name)
+		assert.Equal(t, "edit", cookies[0].Value)
+		assert.True(t, cookies[0].HttpOnly)
+	}
+
+	resp = post(url.Values{"slotId": slots, "csrf": {"c1"}}, csrf, &http.Cookie{Name: editCookie, Value: "edit"})
+	assert.Equal(t, http.StatusSeeOther, resp.Code)
+	svc.AssertExpectations(t)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/gin-gonic/gin"
+)
+
+// findOptions reads the limit, next, sort and fields query parameters shared
+// by the list endpoints.
+func findOptions(c *gin.Context) (query.FindOptions, error) {
+	opts := query.FindOptions{
+		After:  c.Query("next"),
+		Sort:   query.ParseSort(c.Query("sort")),
+		Fields: query.ParseFields(c.Query("fields")),
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || limit < 1 {
+			return opts, fmt.Errorf("invalid limit %q", raw)
+		}
+		opts.Limit = limit
+	}
+	return opts, nil
+}
+
+// pageRequested tells whether the caller asked for one page, with limit or
+// next. GET /events and GET /event/:id/availability returned a bare array of
+// every item before they were paged, and still do without either.
+func pageRequested(c *gin.Context) bool {
+	return c.Query("limit") != "" || c.Query("next") != ""
+}
+
+// listAll reads every item of a paged list, following its pages from the
+// first to the last.
+func listAll[T any](opts query.FindOptions, list func(query.FindOptions) (*query.Page[T], error)) (*query.Page[T], error) {
+	opts.Limit = query.MaxPageSize
+	all := &query.Page[T]{Items: []T{}}
+	for {
+		page, err := list(opts)
+		if err != nil {
+			return nil, err
+		}
+		all.Items = append(all.Items, page.Items...)
+		all.Total = page.Total
+		if page.Next == "" {
+			return all, nil
+		}
+		opts.After = page.Next
+	}
+}
+
+// listPage reads the page asked for, or every item when no page was, see
+// pageRequested.
+func listPage[T any](c *gin.Context, opts query.FindOptions, list func(query.FindOptions) (*query.Page[T], error)) (*query.Page[T], error) {
+	if pageRequested(c) {
+		return list(opts)
+	}
+	return listAll(opts, list)
+}
+
+// timeParam reads an optional RFC 3339 timestamp or YYYY-MM-DD date (UTC
+// midnight) query parameter.
+func timeParam(c *gin.Context, name string) (time.Time, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q", name, raw)
+	}
+	return t, nil
+}
+
+// projected is a page whose items only carry the requested fields.
+type projected struct {
+	Items []map[string]json.RawMessage `json:"items"`
+	Next  string                       `json:"next,omitempty"`
+	Total int64                        `json:"total"`
+}
+
+// projectPage strips every field not asked for from the JSON form of the page
+// items; the repository already left them empty. The id is always kept. With
+// bare, only the items are returned, see pageRequested.
+func projectPage[T any](page *query.Page[T], fields []string, bare bool) (any, error) {
+	if len(fields) == 0 && bare {
+		return page.Items, nil
+	}
+	if len(fields) == 0 {
+		return page, nil
+	}
+	out := projected{Items: make([]map[string]json.RawMessage, 0, len(page.Items)), Next: page.Next, Total: page.Total}
+	for _, item := range page.Items {
+		b, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(b, &all); err != nil {
+			return nil, err
+		}
+		kept := map[string]json.RawMessage{"id": all["id"]}
+		for _, f := range fields {
+			if v, ok := all[f]; ok {
+				kept[f] = v
+			}
+		}
+		out.Items = append(out.Items, kept)
+	}
+	if bare {
+		return out.Items, nil
+	}
+	return out, nil
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EditTokenHeader carries the secret a guest received when first answering a poll.
+const EditTokenHeader = "X-Edit-Token"
+
+// pollView is the part of an event that is safe to show on a public poll link.
+type pollView struct {
+	ID            primitive.ObjectID `json:"id"`
+	Title         string             `json:"title"`
+	EstimatedMins int                `json:"estimatedMins"`
+	Slots         []models.TimeSlot  `json:"slots"`
+	Status        string             `json:"status"`
+}
+
+type guestAnswer struct {
+	Name    string   `json:"name"`
+	SlotIDs []string `json:"slotIds"`
+}
+
+func ShareEventHandler(svc services.EventService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		event, err := svc.ShareEvent(c, c.Param("id"))
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusNotFound), bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"shareToken": event.ShareToken, "url": "/polls/" + event.ShareToken})
+	}
+}
+
+func GetPollHandler(svc services.GuestService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		event, err := svc.GetSharedEvent(c, c.Param("token"))
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusNotFound), bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, pollView{
+			ID:            event.ID,
+			Title:         event.Title,
+			EstimatedMins: event.EstimatedMins,
+			Slots:         inZone(event.Slots, zoneFor(c, *event)),
+			Status:        event.Status,
+		})
+	}
+}
+
+func RespondToPollHandler(svc services.GuestService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req guestAnswer
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		resp, err := svc.Respond(c, c.Param("token"), req.Name, req.SlotIDs)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, resp)
+	}
+}
+
+func GetPollResponseHandler(svc services.GuestService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := svc.GetResponse(c, c.Param("token"), c.GetHeader(EditTokenHeader))
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func UpdatePollResponseHandler(svc services.GuestService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req guestAnswer
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		resp, err := svc.UpdateResponse(c, c.Param("token"), c.GetHeader(EditTokenHeader), req.SlotIDs)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"iter"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// flushEvery is how many NDJSON lines are buffered before they are pushed to the client.
+const flushEvery = 100
+
+// streamNDJSON writes one JSON document per line as seq yields them, so the
+// response never holds the whole result. An error before the first line is
+// an ordinary JSON error response; once the status is sent, the stream ends
+// with an {"error": ...} line instead.
+func streamNDJSON[T any](c *gin.Context, seq iter.Seq2[T, error]) {
+	enc := json.NewEncoder(c.Writer)
+	started := false
+	start := func() {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+		started = true
+	}
+	n := 0
+	for item, err := range seq {
+		if err != nil {
+			if !started {
+				c.JSON(errorStatus(err, http.StatusNotFound), bson.M{"error": err.Error()})
+				return
+			}
+			enc.Encode(bson.M{"error": err.Error()})
+			break
+		}
+		if !started {
+			start()
+		}
+		if err := enc.Encode(item); err != nil {
+			return // client went away, the deferred cursor close follows
+		}
+		if n++; n%flushEvery == 0 {
+			c.Writer.Flush()
+		}
+	}
+	if !started {
+		start()
+	}
+	c.Writer.Flush()
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// userParam is the :id of the request, "me" standing for the caller.
+func userParam(c *gin.Context) string {
+	if id := c.Param("id"); id != "me" {
+		return id
+	}
+	caller, _ := auth.FromContext(c)
+	return caller.UserID
+}
+
+// GetProfileHandler returns the zone and working hours of a user.
+func GetProfileHandler(svc services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		profile, err := svc.GetProfile(c, userParam(c))
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+// UpdateProfileHandler replaces the profile of a user, their own unless the
+// caller is an admin.
+func UpdateProfileHandler(svc services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var profile models.User
+		if err := c.ShouldBindJSON(&profile); err != nil {
+			c.JSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+			return
+		}
+		saved, err := svc.UpdateProfile(c, userParam(c), profile)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), bson.M{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, saved)
+	}
+}
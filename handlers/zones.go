@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// zoneKey holds the resolver ViewerZone leaves on the gin context.
+const zoneKey = "viewerZone"
+
+// ViewerZone picks the zone responses render times in: ?tz= (an IANA name),
+// else the zone of the caller's profile. users is nil where there is no
+// caller, on public poll links. The profile is only read by handlers that
+// render times, and at most once per request.
+func ViewerZone(users services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tz := c.Query("tz"); tz != "" {
+			loc, err := services.LoadZone(tz)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, bson.M{"error": err.Error()})
+				return
+			}
+			c.Set(zoneKey, func() *time.Location { return loc })
+			return
+		}
+		if users == nil {
+			return
+		}
+		c.Set(zoneKey, sync.OnceValue(func() *time.Location {
+			id, ok := auth.FromContext(c)
+			if !ok {
+				return nil
+			}
+			profile, err := users.GetProfile(c, id.UserID)
+			if err != nil {
+				// a rendering preference is not worth failing the request for
+				slog.WarnContext(c, "viewer profile", "userId", id.UserID, "error", err)
+				return nil
+			}
+			if profile.TimeZone == "" {
+				return nil
+			}
+			loc, err := services.LoadZone(profile.TimeZone)
+			if err != nil {
+				return nil
+			}
+			return loc
+		}))
+	}
+}
+
+// viewerZone is the zone chosen by ViewerZone, nil when the viewer has none.
+func viewerZone(c *gin.Context) *time.Location {
+	v, _ := c.Get(zoneKey)
+	resolve, ok := v.(func() *time.Location)
+	if !ok {
+		return nil
+	}
+	return resolve()
+}
+
+// zoneFor is where the times of event are rendered: the viewer's zone, else
+// the event's own, else UTC.
+func zoneFor(c *gin.Context, event models.Event) *time.Location {
+	if loc := viewerZone(c); loc != nil {
+		return loc
+	}
+	if loc, err := services.LoadZone(event.TimeZone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// inZone returns a copy of slots with their times in loc. The instants do not
+// change, only the offset they are written with.
+func inZone(slots []models.TimeSlot, loc *time.Location) []models.TimeSlot {
+	if slots == nil {
+		return nil
+	}
+	out := make([]models.TimeSlot, len(slots))
+	for i, s := range slots {
+		s.StartTime, s.EndTime = s.StartTime.In(loc), s.EndTime.In(loc)
+		out[i] = s
+	}
+	return out
+}
+
+// localize renders the times of event in zoneFor. Slices are replaced rather
+// than written to, event may be shared with the service that returned it.
+func localize(c *gin.Context, event *models.Event) {
+	loc := zoneFor(c, *event)
+	event.Slots = inZone(event.Slots, loc)
+	if event.Recurrence != nil {
+		rec := *event.Recurrence
+		rec.ExDates = make([]time.Time, len(rec.ExDates))
+		for i, t := range event.Recurrence.ExDates {
+			rec.ExDates[i] = t.In(loc)
+		}
+		event.Recurrence = &rec
+	}
+}
+
+// localizePage applies localize to every event of a page.
+func localizePage(c *gin.Context, events []models.Event) {
+	for i := range events {
+		localize(c, &events[i])
+	}
+}
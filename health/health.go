@@ -0,0 +1,83 @@
+// Package health tracks what the readiness probe reports: whether the
+// database answers, whether the server is shutting down and how the
+// background workers last ran.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Worker is the outcome of a background worker's last run.
+type Worker struct {
+	LastRun   time.Time `json:"lastRun"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// Report is the readiness of the server. Worker failures are reported but do
+// not make the server unready: it can still serve requests.
+type Report struct {
+	Ready    bool              `json:"ready"`
+	Draining bool              `json:"draining,omitempty"`
+	Database string            `json:"database"`
+	Workers  map[string]Worker `json:"workers,omitempty"`
+}
+
+// Checker answers readiness probes. Ping checks the database; a nil Ping
+// treats it as always reachable.
+type Checker struct {
+	Ping    func(ctx context.Context) error
+	Timeout time.Duration // bound on Ping, none when zero
+
+	draining atomic.Bool
+	mu       sync.Mutex
+	workers  map[string]Worker
+}
+
+// Drain marks the server as shutting down so load balancers stop routing to it
+// while in-flight requests finish.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// ReportRun records the outcome of one run of the named background worker.
+func (c *Checker) ReportRun(worker string, err error) {
+	w := Worker{LastRun: time.Now().UTC()}
+	if err != nil {
+		w.LastError = err.Error()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.workers == nil {
+		c.workers = map[string]Worker{}
+	}
+	c.workers[worker] = w
+}
+
+// Check runs the readiness checks.
+func (c *Checker) Check(ctx context.Context) Report {
+	r := Report{Draining: c.draining.Load(), Database: "ok"}
+	if c.Ping != nil {
+		if c.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+			defer cancel()
+		}
+		if err := c.Ping(ctx); err != nil {
+			r.Database = err.Error()
+		}
+	}
+	r.Ready = !r.Draining && r.Database == "ok"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.workers) > 0 {
+		r.Workers = make(map[string]Worker, len(c.workers))
+		for name, w := range c.workers {
+			r.Workers[name] = w
+		}
+	}
+	return r
+}
@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	var down error
+	c := &Checker{Ping: func(context.Context) error { return down }}
+
+	r := c.Check(context.Background())
+	assert.True(t, r.Ready)
+	assert.Equal(t, "ok", r.Database)
+	assert.Nil(t, r.Workers)
+
+	down = errors.New("no reachable servers")
+	r = c.Check(context.Background())
+	assert.False(t, r.Ready)
+	assert.Equal(t, "no reachable servers", r.Database)
+
+	down = nil
+	c.Drain()
+	r = c.Check(context.Background())
+	assert.False(t, r.Ready)
+	assert.True(t, r.Draining)
+}
+
+func TestCheckTimeout(t *testing.T) {
+	c := &Checker{Timeout: time.Millisecond, Ping: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	r := c.Check(context.Background())
+	assert.False(t, r.Ready)
+	assert.Equal(t, context.DeadlineExceeded.Error(), r.Database)
+}
+
+func TestReportRun(t *testing.T) {
+	c := &Checker{}
+	c.ReportRun("purge", errors.New("boom"))
+	c.ReportRun("digest", nil)
+
+	r := c.Check(context.Background())
+	assert.True(t, r.Ready, "worker failures do not take the server out of rotation")
+	assert.Equal(t, "boom", r.Workers["purge"].LastError)
+	assert.Empty(t, r.Workers["digest"].LastError)
+	assert.False(t, r.Workers["digest"].LastRun.IsZero())
+}
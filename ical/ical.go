@@ -0,0 +1,187 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ProdID identifies this service as the producer of exported calendars.
+const ProdID = "-//scheduling-system//EN"
+
+const (
+	PartStatAccepted = "ACCEPTED"
+	PartStatDeclined = "DECLINED"
+
+	// maxLineOctets is the RFC 5545 limit before a content line must be folded.
+	maxLineOctets = 75
+	dateTimeUTC   = "20060102T150405Z"
+	dateTimeLocal = "20060102T150405"
+)
+
+// Attendee is an ORGANIZER or ATTENDEE property.
+type Attendee struct {
+	UserID   string
+	Name     string
+	PartStat string // empty for the organizer
+}
+
+// Event is a single VEVENT.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	Stamp       time.Time
+	Organizer   *Attendee
+	Attendees   []Attendee
+	Transparent bool        // TRANSP:TRANSPARENT, does not block the attendee's time
+	RRule       string      // RRULE value, repeats the event from Start
+	ExDates     []time.Time // occurrence starts left out of RRule
+	TimeZone    string      // IANA zone Start, End and ExDates are written in with TZID, UTC when empty
+}
+
+// CalAddress turns a user id into a cal-address URI. Email-like ids become
+// mailto: addresses, anything else a URN.
+func CalAddress(userID string) string {
+	if strings.Contains(userID, "@") {
+		return "mailto:" + userID
+	}
+	return "urn:x-scheduling-system:user:" + userID
+}
+
+// EscapeText escapes a TEXT property value. Line breaks become \n, other
+// control characters but tabs are dropped so a value can never end its line.
+func EscapeText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return stripControls(r.Replace(s), '\t')
+}
+
+// quoteParam quotes a parameter value, which can hold neither double quotes
+// nor control characters.
+func quoteParam(s string) string {
+	return `"` + stripControls(strings.ReplaceAll(s, `"`, "'"), -1) + `"`
+}
+
+// stripControls drops the control characters of s except keep.
+func stripControls(s string, keep rune) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != keep {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Writer writes CRLF terminated, folded content lines.
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Line writes "name:value", folding it at 75 octets without splitting UTF-8 sequences.
+func (w *Writer) Line(name, value string) {
+	line := name + ":" + value
+	for len(line) > maxLineOctets {
+		cut := maxLineOctets
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.write(line[:cut] + "\r\n")
+		line = " " + line[cut:]
+	}
+	w.write(line + "\r\n")
+}
+
+func isRuneStart(b byte) bool { return b&0xC0 != 0x80 }
+
+func (w *Writer) write(s string) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = w.w.WriteString(s)
+}
+
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+func (w *Writer) attendee(prop string, a Attendee) {
+	name := prop
+	if a.Name != "" {
+		name += ";CN=" + quoteParam(a.Name)
+	}
+	if a.PartStat != "" {
+		name += ";ROLE=REQ-PARTICIPANT;PARTSTAT=" + a.PartStat
+	}
+	w.Line(name, CalAddress(a.UserID))
+}
+
+// dateTime writes t in zone with a TZID parameter, or in UTC when zone is
+// empty or unknown. Clients repeat an RRULE in the zone of its DTSTART, which
+// WriteCalendar defines in a VTIMEZONE.
+func (w *Writer) dateTime(prop string, t time.Time, zone string) {
+	if loc, ok := eventZone(zone); ok {
+		w.Line(prop+";TZID="+zone, t.In(loc).Format(dateTimeLocal))
+		return
+	}
+	w.Line(prop, t.UTC().Format(dateTimeUTC))
+}
+
+func (w *Writer) Event(e Event) {
+	w.Line("BEGIN", "VEVENT")
+	w.Line("UID", e.UID)
+	w.Line("DTSTAMP", e.Stamp.UTC().Format(dateTimeUTC))
+	w.dateTime("DTSTART", e.Start, e.TimeZone)
+	w.dateTime("DTEND", e.End, e.TimeZone)
+	if e.RRule != "" {
+		w.Line("RRULE", e.RRule)
+	}
+	for _, t := range e.ExDates {
+		w.dateTime("EXDATE", t, e.TimeZone)
+	}
+	w.Line("SUMMARY", EscapeText(e.Summary))
+	if e.Description != "" {
+		w.Line("DESCRIPTION", EscapeText(e.Description))
+	}
+	if e.Organizer != nil {
+		w.attendee("ORGANIZER", *e.Organizer)
+	}
+	for _, a := range e.Attendees {
+		w.attendee("ATTENDEE", a)
+	}
+	w.Line("END", "VEVENT")
+}
+
+// WriteCalendar writes a complete VCALENDAR containing events.
+func WriteCalendar(out io.Writer, name string, events []Event) error {
+	w := NewWriter(out)
+	w.Line("BEGIN", "VCALENDAR")
+	w.Line("VERSION", "2.0")
+	w.Line("PRODID", ProdID)
+	w.Line("CALSCALE", "GREGORIAN")
+	w.Line("METHOD", "PUBLISH")
+	if name != "" {
+		w.Line("X-WR-CALNAME", EscapeText(name))
+	}
+	zones, spans := zoneSpans(events)
+	for _, zone := range zones {
+		loc, _ := eventZone(zone)
+		w.timeZone(loc, spans[zone][0], spans[zone][1])
+	}
+	for _, e := range events {
+		w.Event(e)
+	}
+	w.Line("END", "VCALENDAR")
+	return w.Flush()
+}
@@ -0,0 +1,133 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteCalendar(t *testing.T) {
+	start := time.Date(2025, 5, 6, 16, 0, 0, 0, time.FixedZone("IST", 5*3600+1800))
+	var buf bytes.Buffer
+	err := WriteCalendar(&buf, "Team", []Event{{
+		UID:       "abc@scheduling-system",
+		Summary:   "Retro; planning, Q3",
+		Start:     start,
+		End:       start.Add(30 * time.Minute),
+		Stamp:     start,
+		Organizer: &Attendee{UserID: "owner@example.com", Name: "Owner"},
+		Attendees: []Attendee{{UserID: "u1", PartStat: PartStatAccepted}},
+	}})
+	assert.NoError(t, err)
+
+	out := strings.ReplaceAll(buf.String(), "\r\n ", "") // unfold
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.Contains(t, out, "DTSTART:20250506T103000Z\r\n")
+	assert.Contains(t, out, `SUMMARY:Retro\; planning\, Q3`+"\r\n")
+	assert.Contains(t, out, "ORGANIZER;CN=\"Owner\":mailto:owner@example.com\r\n")
+	assert.Contains(t, out, "ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT=ACCEPTED:urn:x-scheduling-system:user:u1\r\n")
+	assert.True(t, strings.HasSuffix(out, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+}
+
+func TestWriteRecurringEvent(t *testing.T) {
+	start := time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	err := WriteCalendar(&buf, "", []Event{{UID: "x", Start: start, End: start.Add(time.Hour), Stamp: start,
+		RRule: "FREQ=WEEKLY;BYDAY=TU", ExDates: []time.Time{start.AddDate(0, 0, 7)}}})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "RRULE:FREQ=WEEKLY;BYDAY=TU\r\nEXDATE:20250513T100000Z\r\n")
+
+	buf.Reset()
+	err = WriteCalendar(&buf, "", []Event{{UID: "x", Start: start, End: start.Add(time.Hour), Stamp: start,
+		RRule: "FREQ=WEEKLY;BYDAY=TU", ExDates: []time.Time{start.AddDate(0, 0, 7)}, TimeZone: "America/New_York"}})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "DTSTART;TZID=America/New_York:20250506T060000\r\n")
+	assert.Contains(t, buf.String(), "EXDATE;TZID=America/New_York:20250513T060000\r\n")
+}
+
+func TestWriteTimeZones(t *testing.T) {
+	start := time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC)
+	event := func(zone string) Event {
+		return Event{UID: zone, Start: start, End: start.Add(time.Hour), Stamp: start, RRule: "FREQ=WEEKLY", TimeZone: zone}
+	}
+	var buf bytes.Buffer
+	err := WriteCalendar(&buf, "", []Event{event("Europe/Berlin"), event("Asia/Kolkata"), event("Europe/Berlin"), event("")})
+	assert.NoError(t, err)
+	out := buf.String()
+
+	// one per zone used, before the events
+	assert.Equal(t, 2, strings.Count(out, "BEGIN:VTIMEZONE\r\n"))
+	assert.Less(t, strings.LastIndex(out, "END:VTIMEZONE"), strings.Index(out, "BEGIN:VEVENT"))
+	assert.Contains(t, out, "BEGIN:VTIMEZONE\r\nTZID:Asia/Kolkata\r\n"+
+		"BEGIN:STANDARD\r\nDTSTART:19700101T000000\r\nTZOFFSETFROM:+0530\r\nTZOFFSETTO:+0530\r\nTZNAME:IST\r\nEND:STANDARD\r\n"+
+		"END:VTIMEZONE\r\n")
+	assert.Contains(t, out, "BEGIN:VTIMEZONE\r\nTZID:Europe/Berlin\r\n"+
+		"BEGIN:DAYLIGHT\r\nDTSTART:20240331T020000\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU\r\nTZNAME:CEST\r\nEND:DAYLIGHT\r\n"+
+		"BEGIN:STANDARD\r\nDTSTART:20241027T030000\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\nRRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU\r\nTZNAME:CET\r\nEND:STANDARD\r\n"+
+		"END:VTIMEZONE\r\n")
+}
+
+func TestTimeZoneRules(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	ny, _ := time.LoadLocation("America/New_York")
+	w.timeZone(ny, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, w.Flush())
+	assert.Contains(t, buf.String(), "RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU\r\n")
+	assert.Contains(t, buf.String(), "RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU\r\n")
+
+	// the rules changed in 2007, earlier changes are listed one by one
+	buf.Reset()
+	w.timeZone(ny, time.Date(2006, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2008, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, w.Flush())
+	assert.Contains(t, buf.String(), "DTSTART:20050403T020000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0400\r\nTZNAME:EDT\r\n")
+	assert.Contains(t, buf.String(), "DTSTART:20070311T020000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0400\r\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU\r\n")
+	assert.Equal(t, 1, strings.Count(buf.String(), "BYMONTH=3"))
+
+	// Turkey left daylight saving time in 2016
+	buf.Reset()
+	istanbul, _ := time.LoadLocation("Europe/Istanbul")
+	w.timeZone(istanbul, time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, w.Flush())
+	assert.NotContains(t, buf.String(), "RRULE")
+}
+
+func TestWriteStripsControlCharacters(t *testing.T) {
+	start := time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	err := WriteCalendar(&buf, "", []Event{{UID: "x", Start: start, End: start.Add(time.Hour), Stamp: start,
+		Summary:   "Retro\rBEGIN:VALARM",
+		Attendees: []Attendee{{UserID: "guest:1", Name: "x\r\nATTENDEE:mailto:evil", PartStat: PartStatAccepted}}}})
+	assert.NoError(t, err)
+
+	out := strings.ReplaceAll(buf.String(), "\r\n ", "") // unfold
+	assert.Contains(t, out, `ATTENDEE;CN="xATTENDEE:mailto:evil";ROLE=`)
+	assert.Contains(t, out, "SUMMARY:RetroBEGIN:VALARM\r\n")
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.NotContains(t, line, "\r")
+		assert.NotContains(t, line, "\n")
+		assert.False(t, strings.HasPrefix(line, "ATTENDEE:"), line)
+	}
+	assert.Equal(t, `Line one\nline two`, EscapeText("Line one\r\nline two"))
+}
+
+func TestLineFolding(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Line("DESCRIPTION", strings.Repeat("é", 60))
+	assert.NoError(t, w.Flush())
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	assert.Greater(t, len(lines), 1)
+	for i, l := range lines {
+		assert.LessOrEqual(t, len(l), 75)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(l, " "))
+		}
+	}
+	unfolded := strings.ReplaceAll(buf.String(), "\r\n ", "")
+	assert.Equal(t, "DESCRIPTION:"+strings.Repeat("é", 60)+"\r\n", unfolded)
+}
@@ -0,0 +1,277 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FBTypeBusy = "BUSY"
+	FBTypeFree = "FREE"
+)
+
+var ErrNoCalendar = errors.New("no VCALENDAR found")
+
+// Period is a free/busy interval from a VFREEBUSY component.
+type Period struct {
+	Start time.Time
+	End   time.Time
+	Type  string // FBTypeBusy, FBTypeFree or another FBTYPE value
+}
+
+// Calendar is the subset of a parsed VCALENDAR the scheduler understands.
+type Calendar struct {
+	Name     string
+	Events   []Event
+	FreeBusy []Period
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// unfold reads content lines, joining folded continuation lines.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, sc.Err()
+}
+
+func parseProperty(line string) (property, error) {
+	// the value starts at the first colon outside a quoted parameter value
+	inQuote := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuote = !inQuote
+		} else if r == ':' && !inQuote {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, fmt.Errorf("malformed content line %q", line)
+	}
+	parts := strings.Split(line[:colon], ";")
+	p := property{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, nil
+}
+
+func unescapeText(s string) string {
+	r := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return r.Replace(s)
+}
+
+// parseDateTime understands UTC, TZID qualified and floating DATE-TIME values
+// as well as plain DATE values. Floating times and dates are read in floating.
+// A TZID that is not an IANA zone is an error, guessing would shift the time.
+func parseDateTime(value string, params map[string]string, floating *time.Location) (time.Time, error) {
+	loc := floating
+	if tzid := params["TZID"]; tzid != "" {
+		l, err := time.LoadLocation(tzid)
+		if err != nil || tzid == "Local" {
+			return time.Time{}, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		loc = l
+	}
+	switch {
+	case params["VALUE"] == "DATE" || len(value) == 8:
+		return time.ParseInLocation("20060102", value, loc)
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse(dateTimeUTC, value)
+	default:
+		return time.ParseInLocation("20060102T150405", value, loc)
+	}
+}
+
+var durationRe = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseDuration parses an RFC 5545 DURATION value such as "PT1H30M" or "P1W".
+func ParseDuration(value string) (time.Duration, error) {
+	m := durationRe.FindStringSubmatch(value)
+	if m == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(m[i+2])
+		d += time.Duration(n) * unit
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// parsePeriod parses "start/end" or "start/duration".
+func parsePeriod(value string, fbType string, floating *time.Location) (Period, error) {
+	startRaw, endRaw, ok := strings.Cut(value, "/")
+	if !ok {
+		return Period{}, fmt.Errorf("invalid period %q", value)
+	}
+	start, err := parseDateTime(startRaw, nil, floating)
+	if err != nil {
+		return Period{}, err
+	}
+	p := Period{Start: start, Type: fbType}
+	if strings.HasPrefix(endRaw, "P") || strings.HasPrefix(endRaw, "+P") {
+		d, err := ParseDuration(endRaw)
+		if err != nil {
+			return Period{}, err
+		}
+		p.End = start.Add(d)
+	} else if p.End, err = parseDateTime(endRaw, nil, floating); err != nil {
+		return Period{}, err
+	}
+	return p, nil
+}
+
+// Parse reads VEVENT and VFREEBUSY components from an iCalendar stream. Other
+// components, such as VTIMEZONE or VALARM, are skipped. Floating times, which
+// name no zone, are read in floating: the zone of whoever made the calendar,
+// as far as the caller knows it. nil stands for UTC.
+func Parse(r io.Reader, floating *time.Location) (*Calendar, error) {
+	if floating == nil {
+		floating = time.UTC
+	}
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	cal := &Calendar{}
+	var (
+		stack    []string
+		found    bool
+		event    *Event
+		duration time.Duration
+	)
+	for _, line := range lines {
+		p, err := parseProperty(line)
+		if err != nil {
+			return nil, err
+		}
+		switch p.name {
+		case "BEGIN":
+			comp := strings.ToUpper(p.value)
+			stack = append(stack, comp)
+			switch comp {
+			case "VCALENDAR":
+				found = true
+			case "VEVENT":
+				event, duration = &Event{}, 0
+			}
+			continue
+		case "END":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("unexpected END:%s", p.value)
+			}
+			comp := stack[len(stack)-1]
+			if !strings.EqualFold(p.value, comp) {
+				return nil, fmt.Errorf("END:%s does not close BEGIN:%s", p.value, comp)
+			}
+			stack = stack[:len(stack)-1]
+			if comp == "VEVENT" && event != nil {
+				if event.End.IsZero() {
+					event.End = event.Start.Add(duration)
+				}
+				if event.Start.IsZero() || !event.End.After(event.Start) {
+					return nil, fmt.Errorf("event %q has no valid start and end", event.Summary)
+				}
+				cal.Events = append(cal.Events, *event)
+				event = nil
+			}
+			continue
+		}
+		if len(stack) == 0 {
+			continue
+		}
+		switch current := stack[len(stack)-1]; {
+		case current == "VCALENDAR" && p.name == "X-WR-CALNAME":
+			cal.Name = unescapeText(p.value)
+		case current == "VEVENT" && event != nil:
+			if err := setEventProperty(event, &duration, p, floating); err != nil {
+				return nil, err
+			}
+		case current == "VFREEBUSY" && p.name == "FREEBUSY":
+			fbType := strings.ToUpper(p.params["FBTYPE"])
+			if fbType == "" {
+				fbType = FBTypeBusy
+			}
+			for _, raw := range strings.Split(p.value, ",") {
+				period, err := parsePeriod(raw, fbType, floating)
+				if err != nil {
+					return nil, err
+				}
+				cal.FreeBusy = append(cal.FreeBusy, period)
+			}
+		}
+	}
+	if !found {
+		return nil, ErrNoCalendar
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("BEGIN:%s is never closed", stack[len(stack)-1])
+	}
+	return cal, nil
+}
+
+func setEventProperty(e *Event, duration *time.Duration, p property, floating *time.Location) error {
+	var err error
+	switch p.name {
+	case "UID":
+		e.UID = p.value
+	case "SUMMARY":
+		e.Summary = unescapeText(p.value)
+	case "DESCRIPTION":
+		e.Description = unescapeText(p.value)
+	case "DTSTART":
+		e.Start, err = parseDateTime(p.value, p.params, floating)
+		if err == nil && (p.params["VALUE"] == "DATE" || len(p.value) == 8) {
+			*duration = 24 * time.Hour // all-day event without DTEND
+		}
+	case "DTEND":
+		e.End, err = parseDateTime(p.value, p.params, floating)
+	case "DURATION":
+		*duration, err = ParseDuration(p.value)
+	case "TRANSP":
+		e.Transparent = strings.EqualFold(p.value, "TRANSPARENT")
+	case "RRULE":
+		e.RRule = p.value
+	case "EXDATE":
+		for _, raw := range strings.Split(p.value, ",") {
+			t, err := parseDateTime(raw, p.params, floating)
+			if err != nil {
+				return err
+			}
+			e.ExDates = append(e.ExDates, t)
+		}
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sample = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"X-WR-CALNAME:Planning\\, Q3\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:1\r\n" +
+	"SUMMARY:Option A\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20250506T160000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20250506T163000\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"TRIGGER:-PT15M\r\n" +
+	"DURATION:PT5M\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:2\r\n" +
+	"SUMMARY:Option B with a long\r\n" +
+	"  folded summary\r\n" +
+	"DTSTART:20250507T090000Z\r\n" +
+	"DURATION:PT1H30M\r\n" +
+	"TRANSP:TRANSPARENT\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VFREEBUSY\r\n" +
+	"DTSTART:20250506T000000Z\r\n" +
+	"DTEND:20250508T000000Z\r\n" +
+	"FREEBUSY;FBTYPE=FREE:20250506T080000Z/20250506T120000Z,20250507T080000Z/PT2H\r\n" +
+	"FREEBUSY:20250506T130000Z/20250506T140000Z\r\n" +
+	"END:VFREEBUSY\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	cal, err := Parse(strings.NewReader(sample), nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Planning, Q3", cal.Name)
+	assert.Len(t, cal.Events, 2)
+	assert.True(t, cal.Events[0].Start.Equal(time.Date(2025, 5, 6, 14, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 30*time.Minute, cal.Events[0].End.Sub(cal.Events[0].Start))
+	assert.Equal(t, "Option B with a long folded summary", cal.Events[1].Summary)
+	assert.Equal(t, 90*time.Minute, cal.Events[1].End.Sub(cal.Events[1].Start))
+	assert.True(t, cal.Events[1].Transparent)
+
+	assert.Len(t, cal.FreeBusy, 3)
+	assert.Equal(t, FBTypeFree, cal.FreeBusy[1].Type)
+	assert.True(t, cal.FreeBusy[1].End.Equal(time.Date(2025, 5, 7, 10, 0, 0, 0, time.UTC)))
+	assert.Equal(t, FBTypeBusy, cal.FreeBusy[2].Type)
+}
+
+func TestParseRecurrence(t *testing.T) {
+	cal, err := Parse(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n"+
+		"DTSTART:20250506T100000Z\r\nDTEND:20250506T110000Z\r\n"+
+		"RRULE:FREQ=WEEKLY;BYDAY=TU\r\n"+
+		"EXDATE;TZID=Europe/Berlin:20250513T120000,20250520T120000\r\n"+
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"), nil)
+	assert.NoError(t, err)
+	e := cal.Events[0]
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=TU", e.RRule)
+	if assert.Len(t, e.ExDates, 2) {
+		assert.True(t, e.ExDates[0].Equal(time.Date(2025, 5, 13, 10, 0, 0, 0, time.UTC)))
+	}
+}
+
+func TestParseFloatingTimes(t *testing.T) {
+	kolkata, _ := time.LoadLocation("Asia/Kolkata")
+	floating := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n" +
+		"DTSTART:20250506T100000\r\nDTEND:20250506T110000\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	cal, err := Parse(strings.NewReader(floating), kolkata)
+	assert.NoError(t, err)
+	assert.True(t, cal.Events[0].Start.Equal(time.Date(2025, 5, 6, 4, 30, 0, 0, time.UTC)))
+
+	cal, err = Parse(strings.NewReader(floating), nil)
+	assert.NoError(t, err)
+	assert.True(t, cal.Events[0].Start.Equal(time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC)))
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, in := range map[string]string{
+		"no content line": "hello",
+		"no calendar":     "BEGIN:VEVENT\r\nEND:VEVENT\r\n",
+		"unknown TZID": "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n" +
+			"DTSTART;TZID=W. Europe Standard Time:20250506T100000\r\nDURATION:PT1H\r\n" +
+			"END:VEVENT\r\nEND:VCALENDAR\r\n",
+		"END of another component": "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n" +
+			"DTSTART:20250506T100000Z\r\nDURATION:PT1H\r\n" +
+			"END:VCALENDAR\r\nEND:VEVENT\r\n",
+		"never closed": "BEGIN:VCALENDAR\r\nBEGIN:VFREEBUSY\r\nEND:VFREEBUSY\r\n",
+	} {
+		_, err := Parse(strings.NewReader(in), nil)
+		assert.Error(t, err, name)
+	}
+	_, err := Parse(strings.NewReader(""), nil)
+	assert.ErrorIs(t, err, ErrNoCalendar)
+}
+
+func TestParseDuration(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"P1W":     7 * 24 * time.Hour,
+		"P1DT2H":  26 * time.Hour,
+		"-PT15M":  -15 * time.Minute,
+	} {
+		got, err := ParseDuration(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"P", "PT", "1H", "PT1X"} {
+		_, err := ParseDuration(in)
+		assert.Error(t, err, in)
+	}
+}
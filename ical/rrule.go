@@ -0,0 +1,104 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+var ErrInvalidRRule = errors.New("invalid RRULE")
+
+// MaxCount bounds COUNT, longer series are written with UNTIL.
+const MaxCount = 1000
+
+// parseRRule reads an RRULE value ("FREQ=WEEKLY;BYDAY=TU;COUNT=10") and
+// anchors it at dtstart. Weekdays and times of day are taken in the location
+// of dtstart.
+//
+// Only the rules of a standing meeting are accepted: DAILY, WEEKLY, and
+// MONTHLY by weekday ("BYDAY=2TU"), at most one occurrence per day. Anything
+// finer could make a single expansion walk millions of occurrences.
+func parseRRule(value string, dtstart time.Time) (*rrule.ROption, error) {
+	opt, err := rrule.StrToROptionInLocation(value, dtstart.Location())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+	}
+	if !opt.Dtstart.IsZero() {
+		return nil, fmt.Errorf("%w: DTSTART comes from the slot", ErrInvalidRRule)
+	}
+	switch {
+	case opt.Freq != rrule.DAILY && opt.Freq != rrule.WEEKLY && opt.Freq != rrule.MONTHLY:
+		return nil, fmt.Errorf("%w: FREQ must be DAILY, WEEKLY or MONTHLY", ErrInvalidRRule)
+	case opt.Freq == rrule.MONTHLY && len(opt.Byweekday) == 0:
+		return nil, fmt.Errorf("%w: MONTHLY needs BYDAY", ErrInvalidRRule)
+	case len(opt.Byhour) > 0 || len(opt.Byminute) > 0 || len(opt.Bysecond) > 0:
+		return nil, fmt.Errorf("%w: the time of day comes from the slot", ErrInvalidRRule)
+	case opt.Count > MaxCount:
+		return nil, fmt.Errorf("%w: COUNT above %d", ErrInvalidRRule, MaxCount)
+	}
+	opt.Dtstart = dtstart
+	if _, err := rrule.NewRRule(*opt); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+	}
+	return opt, nil
+}
+
+// ValidateRRule reports whether value is an RRULE this package can expand.
+func ValidateRRule(value string) error {
+	_, err := parseRRule(value, time.Unix(0, 0).UTC())
+	return err
+}
+
+// seek moves the start of a rule forward by whole periods to at most from, so
+// a window far from the first occurrence is reached without walking every
+// occurrence in between. Moving by whole days or weeks in the rule's location
+// keeps the weekday and the local time of day. Rules with COUNT keep their
+// start, the count runs from it, but they are short. Monthly rules are cheap
+// to walk and keep theirs too.
+func seek(opt rrule.ROption, from time.Time) time.Time {
+	start := opt.Dtstart
+	if opt.Count > 0 || !from.After(start) {
+		return start
+	}
+	days := 1
+	if opt.Freq == rrule.WEEKLY {
+		days = 7
+	} else if opt.Freq != rrule.DAILY {
+		return start
+	}
+	period := days * max(opt.Interval, 1)
+	// in seconds, a Duration overflows after 292 years; one period short, a
+	// DST change makes some days 23 hours long
+	n := int((from.Unix()-start.Unix())/(24*60*60))/period - 1
+	if n <= 0 {
+		return start
+	}
+	return start.AddDate(0, 0, n*period)
+}
+
+// Expand lists, in order, the starts of the occurrences in [from, to) of the
+// RRULE value anchored at dtstart, leaving out exdates. It stops after max
+// occurrences.
+func Expand(value string, dtstart time.Time, exdates []time.Time, from, to time.Time, max int) ([]time.Time, error) {
+	opt, err := parseRRule(value, dtstart)
+	if err != nil {
+		return nil, err
+	}
+	opt.Dtstart = seek(*opt, from)
+	rule, err := rrule.NewRRule(*opt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+	}
+	var starts []time.Time
+	next := rule.Iterator()
+	for t, ok := next(); ok && t.Before(to) && len(starts) < max; t, ok = next() {
+		if t.Before(from) || slices.ContainsFunc(exdates, t.Equal) {
+			continue
+		}
+		starts = append(starts, t)
+	}
+	return starts, nil
+}
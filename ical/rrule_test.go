@@ -0,0 +1,67 @@
+package ical
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teambition/rrule-go"
+)
+
+func TestExpand(t *testing.T) {
+	tuesday := time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC)
+	end := tuesday.AddDate(1, 0, 0)
+
+	weekly, err := Expand("FREQ=WEEKLY;BYDAY=TU;COUNT=4", tuesday, []time.Time{tuesday.AddDate(0, 0, 7)}, tuesday, end, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{tuesday, tuesday.AddDate(0, 0, 14), tuesday.AddDate(0, 0, 21)}, weekly)
+
+	secondTuesday := tuesday.AddDate(0, 0, 7)
+	monthly, err := Expand("FREQ=MONTHLY;BYDAY=2TU;UNTIL=20250901T000000Z", secondTuesday, nil, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), end, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 6, 10, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 7, 8, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 8, 12, 10, 0, 0, 0, time.UTC),
+	}, monthly)
+
+	endless, err := Expand("FREQ=DAILY", tuesday, nil, tuesday, end, 3)
+	assert.NoError(t, err)
+	assert.Len(t, endless, 3)
+}
+
+func TestValidateRRule(t *testing.T) {
+	assert.NoError(t, ValidateRRule("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"))
+	assert.NoError(t, ValidateRRule("FREQ=MONTHLY;BYDAY=-1FR;COUNT=12"))
+	for _, rule := range []string{
+		"", "BYDAY=TU", "FREQ=SOMETIMES", "FREQ=WEEKLY;BYDAY=XX", "FREQ=WEEKLY;DTSTART=20250506T100000Z",
+		"FREQ=SECONDLY", "FREQ=HOURLY", "FREQ=YEARLY", "FREQ=MONTHLY", "FREQ=DAILY;BYHOUR=9,10", "FREQ=DAILY;COUNT=5000",
+	} {
+		assert.ErrorIs(t, ValidateRRule(rule), ErrInvalidRRule, rule)
+	}
+}
+
+func TestExpandSeeksToWindow(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	dtstart := time.Date(2025, 5, 6, 9, 0, 0, 0, berlin)
+	from := time.Date(2028, 3, 20, 0, 0, 0, 0, time.UTC) // spans a DST change
+	to := from.AddDate(0, 1, 0)
+	for _, value := range []string{"FREQ=DAILY;INTERVAL=3", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR"} {
+		opt, err := rrule.StrToROptionInLocation(value, berlin)
+		assert.NoError(t, err)
+		opt.Dtstart = dtstart
+		rule, _ := rrule.NewRRule(*opt)
+		walked := rule.Between(from, to, true)
+
+		seeked, err := Expand(value, dtstart, nil, from, to, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, walked, seeked, value)
+	}
+
+	// far from the first occurrence, this used to walk every day in between
+	started := time.Now()
+	far, err := Expand("FREQ=DAILY", dtstart, nil, time.Date(9000, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(9000, 1, 3, 0, 0, 0, 0, time.UTC), 100)
+	assert.NoError(t, err)
+	assert.Len(t, far, 2)
+	assert.Less(t, time.Since(started), time.Second)
+}
@@ -0,0 +1,212 @@
+package ical
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// transition is a change of the UTC offset of a zone.
+type transition struct {
+	at       time.Time // first instant of the new offset
+	from, to int       // offsets in seconds east of UTC
+	name     string    // abbreviation after the change, "CEST"
+	daylight bool
+	wall     time.Time // at as the clock showed it before the change
+}
+
+// transitions finds the offset changes of loc in [from, to), to the second.
+func transitions(loc *time.Location, from, to time.Time) []transition {
+	var out []transition
+	prev := from.In(loc)
+	for t := from; t.Before(to); t = t.Add(24 * time.Hour) {
+		next := t.Add(24 * time.Hour).In(loc)
+		_, before := prev.Zone()
+		if _, after := next.Zone(); after == before {
+			prev = next
+			continue
+		}
+		// the first second in (prev, next] on the new offset
+		lo, hi := prev.Unix(), next.Unix()
+		for hi-lo > 1 {
+			mid := lo + (hi-lo)/2
+			if _, off := time.Unix(mid, 0).In(loc).Zone(); off == before {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		at := time.Unix(hi, 0).In(loc)
+		name, after := at.Zone()
+		wall := at.UTC().Add(time.Duration(before) * time.Second)
+		out = append(out, transition{at: at, from: before, to: after, name: name, daylight: at.IsDST(), wall: wall})
+		prev = next
+	}
+	return out
+}
+
+// yearlyRule is a transition repeating every year on the nth, or last,
+// weekday of a month at the same wall clock time.
+type yearlyRule struct {
+	month   time.Month
+	weekday time.Weekday
+	clock   time.Duration
+	nth     int  // 1 to 5, 0 when only last fits
+	last    bool // also the last such weekday of the month
+}
+
+func ruleOf(t transition) yearlyRule {
+	w := t.wall
+	days := time.Date(w.Year(), w.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	return yearlyRule{
+		month:   w.Month(),
+		weekday: w.Weekday(),
+		clock:   w.Sub(time.Date(w.Year(), w.Month(), w.Day(), 0, 0, 0, 0, time.UTC)),
+		nth:     (w.Day()-1)/7 + 1,
+		last:    w.Day()+7 > days,
+	}
+}
+
+// merge narrows r to what also describes t, ok false when nothing does.
+func (r yearlyRule) merge(t transition) (yearlyRule, bool) {
+	o := ruleOf(t)
+	if o.month != r.month || o.weekday != r.weekday || o.clock != r.clock {
+		return r, false
+	}
+	if o.nth != r.nth {
+		r.nth = 0
+	}
+	r.last = r.last && o.last
+	return r, r.nth != 0 || r.last
+}
+
+func (r yearlyRule) String() string {
+	n := r.nth
+	if r.last {
+		n = -1 // "last Sunday" rather than a 4th Sunday that happened to be last
+	}
+	return fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", r.month, n, weekdays[r.weekday])
+}
+
+var weekdays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+func formatOffset(sec int) string {
+	sign := "+"
+	if sec < 0 {
+		sign, sec = "-", -sec
+	}
+	s := fmt.Sprintf("%s%02d%02d", sign, sec/3600, sec/60%60)
+	if sec%60 != 0 {
+		s += fmt.Sprintf("%02d", sec%60)
+	}
+	return s
+}
+
+// timeZone writes the VTIMEZONE of loc for times between first and last.
+// Offset changes are listed one by one, except the latest run of each kind
+// following a yearly rule, which is written once with an RRULE so that
+// repeating events stay correct past last.
+func (w *Writer) timeZone(loc *time.Location, first, last time.Time) {
+	// from the year before, the offset in force at first may date from then,
+	// to two years on, so that yearly rules show
+	from := time.Date(first.UTC().Year()-1, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(last.UTC().Year()+2, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.Line("BEGIN", "VTIMEZONE")
+	w.Line("TZID", loc.String())
+	changes := transitions(loc, from, to)
+	if len(changes) == 0 {
+		name, off := from.In(loc).Zone()
+		w.observance(transition{from: off, to: off, name: name, wall: time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)}, "")
+		w.Line("END", "VTIMEZONE")
+		return
+	}
+
+	// walk back from the end while each kind of change keeps to one rule. Only
+	// kinds still seen in the last year go on, the others were abolished.
+	type kind struct {
+		from, to int
+		daylight bool
+	}
+	rules := map[kind]yearlyRule{}
+	ruled := map[kind]int{} // index of the earliest change covered by the rule
+	open := map[kind]bool{}
+	for i := len(changes) - 1; i >= 0; i-- {
+		t := changes[i]
+		k := kind{t.from, t.to, t.daylight}
+		r, seen := rules[k]
+		switch {
+		case !seen && t.at.After(to.AddDate(-1, 0, 0)):
+			rules[k], ruled[k], open[k] = ruleOf(t), i, true
+		case !seen:
+			rules[k], ruled[k] = yearlyRule{}, i+1 // all listed
+		case open[k]:
+			if r, ok := r.merge(t); ok {
+				rules[k], ruled[k] = r, i
+			} else {
+				open[k] = false
+			}
+		}
+	}
+	for i, t := range changes {
+		k := kind{t.from, t.to, t.daylight}
+		switch {
+		case i == ruled[k]:
+			w.observance(t, rules[k].String())
+		case i < ruled[k]:
+			w.observance(t, "")
+		}
+	}
+	w.Line("END", "VTIMEZONE")
+}
+
+func (w *Writer) observance(t transition, rrule string) {
+	comp := "STANDARD"
+	if t.daylight {
+		comp = "DAYLIGHT"
+	}
+	w.Line("BEGIN", comp)
+	w.Line("DTSTART", t.wall.Format(dateTimeLocal))
+	w.Line("TZOFFSETFROM", formatOffset(t.from))
+	w.Line("TZOFFSETTO", formatOffset(t.to))
+	if rrule != "" {
+		w.Line("RRULE", rrule)
+	}
+	w.Line("TZNAME", EscapeText(t.name))
+	w.Line("END", comp)
+}
+
+// zoneSpans returns the zones events are written in with the earliest and
+// latest time written in each, sorted by zone name.
+func zoneSpans(events []Event) (names []string, spans map[string][2]time.Time) {
+	spans = map[string][2]time.Time{}
+	for _, e := range events {
+		if _, ok := eventZone(e.TimeZone); !ok {
+			continue
+		}
+		for _, t := range append([]time.Time{e.Start, e.End}, e.ExDates...) {
+			span, seen := spans[e.TimeZone]
+			if !seen {
+				names = append(names, e.TimeZone)
+				span = [2]time.Time{t, t}
+			}
+			if t.Before(span[0]) {
+				span[0] = t
+			}
+			if t.After(span[1]) {
+				span[1] = t
+			}
+			spans[e.TimeZone] = span
+		}
+	}
+	slices.Sort(names)
+	return names, spans
+}
+
+// eventZone loads the zone an event is written in, false for UTC.
+func eventZone(zone string) (*time.Location, bool) {
+	if zone == "" || zone == "UTC" || zone == "Local" {
+		return nil, false
+	}
+	loc, err := time.LoadLocation(zone)
+	return loc, err == nil
+}
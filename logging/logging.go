@@ -0,0 +1,164 @@
+// Package logging sets up structured JSON logging with log/slog. Records
+// logged with a request context carry the request id, the caller, the
+// organization and the trace id, so one request can be followed across lines.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDKey is the gin context key holding the id of the request.
+const RequestIDKey = "requestId"
+
+// Header carries the request id in both directions.
+const Header = "X-Request-ID"
+
+const maxRequestID = 128
+
+// New returns a logger writing JSON lines to w at level and above.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})})
+}
+
+// RequestID returns the id of the request ctx belongs to, "" outside requests.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
+
+// contextHandler adds the request attributes found on the context of each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("requestId", id))
+		}
+		if user, ok := auth.FromContext(ctx); ok {
+			r.AddAttrs(slog.String("userId", user.UserID), slog.String("authMethod", user.Method))
+		}
+		if org, err := tenant.FromContext(ctx); err == nil {
+			r.AddAttrs(slog.String("orgId", org))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("traceId", sc.TraceID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// validRequestID accepts caller supplied ids that are short and printable, so
+// they cannot forge or bloat log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestID {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// SecretParams are the route parameters holding credentials, the share token of
+// /polls/:token and the feed token of /feeds/:token. Path leaves them out.
+var SecretParams = []string{"token"}
+
+// Path is the request path with the values of SecretParams replaced by their
+// :name, safe to log or put on a span. It is empty for unmatched requests,
+// whose raw path may hold anything.
+func Path(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		return ""
+	}
+	segments := strings.Split(route, "/")
+	for i, s := range segments {
+		if len(s) < 2 || (s[0] != ':' && s[0] != '*') {
+			continue
+		}
+		if name := s[1:]; !slices.Contains(SecretParams, name) {
+			segments[i] = strings.TrimPrefix(c.Param(name), "/")
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Middleware assigns each request an id, reusing a valid X-Request-ID from the
+// caller, echoes it in the response and logs one line per request once it is
+// served: route, status, latency and, through the context, the caller.
+func Middleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader(Header)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set(RequestIDKey, id)
+		c.Header(Header, id)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", Path(c)),
+			slog.Int("status", status),
+			slog.Float64("latencyMs", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("clientIp", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		logger.LogAttrs(c, level, "request", attrs...)
+	}
+}
+
+// GinDebug sends gin's debug mode output, route registrations included, to
+// the default logger at debug level. Install it as gin.DebugPrintFunc.
+func GinDebug(format string, values ...any) {
+	slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)))
+}
+
+// Recovery turns a panic into a 500 and logs it with its stack, in place of
+// gin's plain text recovery output.
+func Recovery(logger *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		logger.ErrorContext(c, "panic", slog.Any("error", err), slog.String("stack", string(debug.Stack())))
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(line), &m), line)
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	router := gin.New()
+	router.Use(Middleware(logger), Recovery(logger))
+	router.GET("/events/:id", func(c *gin.Context) {
+		auth.SetIdentity(c, auth.Identity{UserID: "alice", Method: "jwt"})
+		logger.InfoContext(c, "event finalized", "eventId", c.Param("id"))
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	req, _ := http.NewRequest(http.MethodGet, "/events/e1", nil)
+	req.Header.Set(Header, "abc-123")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, "abc-123", resp.Header().Get(Header))
+
+	logged := lines(t, &buf)
+	assert.Len(t, logged, 2)
+	domain, request := logged[0], logged[1]
+	assert.Equal(t, "event finalized", domain["msg"])
+	assert.Equal(t, "abc-123", domain["requestId"])
+	assert.Equal(t, "alice", domain["userId"])
+	assert.Equal(t, "e1", domain["eventId"])
+
+	assert.Equal(t, "request", request["msg"])
+	assert.Equal(t, "INFO", request["level"])
+	assert.Equal(t, "/events/:id", request["route"])
+	assert.Equal(t, float64(http.StatusNoContent), request["status"])
+	assert.Equal(t, "alice", request["userId"])
+	assert.Contains(t, request, "latencyMs")
+
+	buf.Reset()
+	req, _ = http.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(Header, "spaces are\nnot allowed")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	id := resp.Header().Get(Header)
+	assert.Len(t, id, 36, "a fresh uuid replaces the invalid id")
+
+	logged = lines(t, &buf)
+	assert.Len(t, logged, 2)
+	assert.Equal(t, "panic", logged[0]["msg"])
+	assert.Equal(t, "boom", logged[0]["error"])
+	assert.Equal(t, id, logged[0]["requestId"])
+	assert.Equal(t, "ERROR", logged[1]["level"])
+}
+
+func TestPathMasksTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	router := gin.New()
+	router.Use(Middleware(logger))
+	router.GET("/polls/:token/responses/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/polls/s3cret/responses/me", "/feeds/s3cret/calendar.ics"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.NotContains(t, buf.String(), "s3cret")
+	logged := lines(t, &buf)
+	assert.Equal(t, "/polls/:token/responses/me", logged[0]["path"])
+	assert.Equal(t, "", logged[1]["path"], "unmatched")
+}
+
+func TestRequestIDOutsideRequests(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, slog.LevelInfo).Info("started")
+	assert.NotContains(t, lines(t, &buf)[0], "requestId")
+}
@@ -0,0 +1,126 @@
+// Package metrics holds the Prometheus collectors of the service and serves
+// them on /metrics in the Prometheus text format.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "scheduling"
+
+// Registry holds every collector of the service, plus the Go runtime and
+// process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	factory = promauto.With(Registry)
+
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+	httpDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	repoDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_operation_duration_seconds",
+		Help:      "Repository call latency by collection and operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"collection", "op"})
+	repoErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "repository_errors_total",
+		Help:      "Failed repository calls by collection and operation. Missing documents are not counted.",
+	}, []string{"collection", "op"})
+
+	recommendDuration = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "recommendation_duration_seconds",
+		Help:      "Time to tally the answers of an event and pick its ideal slots.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	historyLost = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "history_entries_lost_total",
+		Help:      "Audit entries and revisions that could not be written after their change was made, by log.",
+	}, []string{"log"})
+
+	openPolls = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "open_polls",
+		Help:      "Events not finalized yet, across organizations.",
+	})
+	responsesLastHour = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "responses_last_hour",
+		Help:      "Availability answers created during the last hour, across organizations.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts and times every request. Routes are labelled by their
+// template ("/events/:id") to keep the label set bounded; requests matching
+// no route share "unmatched".
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveRepo records one repository call started at start. err is the
+// failure to count, nil for calls that succeeded.
+func ObserveRepo(collection, op string, start time.Time, err error) {
+	repoDuration.WithLabelValues(collection, op).Observe(time.Since(start).Seconds())
+	if err != nil {
+		repoErrors.WithLabelValues(collection, op).Inc()
+	}
+}
+
+// ObserveRecommend records one recommendation computed since start.
+func ObserveRecommend(start time.Time) {
+	recommendDuration.Observe(time.Since(start).Seconds())
+}
+
+// HistoryLost counts one entry of log, "audit" or "revisions", that was lost.
+// The change it describes is in the database but missing from the history.
+func HistoryLost(log string) {
+	historyLost.WithLabelValues(log).Inc()
+}
+
+// SetBusiness updates the business gauges, see services.Stats.
+func SetBusiness(open, responsesPerHour int64) {
+	openPolls.Set(float64(open))
+	responsesLastHour.Set(float64(responsesPerHour))
+}
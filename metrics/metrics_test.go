@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/events/:id", func(c *gin.Context) { c.Status(http.StatusTeapot) })
+	router.GET("/metrics", gin.WrapH(Handler()))
+
+	for _, path := range []string{"/events/a", "/events/b", "/nowhere"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/events/:id", "418")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET", "unmatched", "404")))
+
+	req, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `scheduling_http_request_duration_seconds_count{method="GET",route="/events/:id"} 2`)
+	assert.Contains(t, resp.Body.String(), "go_goroutines")
+}
+
+func TestObserveRepo(t *testing.T) {
+	ObserveRepo("events", "GetByID", time.Now(), nil)
+	ObserveRepo("events", "GetByID", time.Now(), errors.New("timeout"))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(repoErrors.WithLabelValues("events", "GetByID")))
+	assert.Equal(t, 1, testutil.CollectAndCount(repoDuration))
+}
+
+func TestHistoryLost(t *testing.T) {
+	HistoryLost("audit")
+	HistoryLost("audit")
+	assert.Equal(t, 2.0, testutil.ToFloat64(historyLost.WithLabelValues("audit")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(historyLost.WithLabelValues("revisions")))
+}
+
+func TestSetBusiness(t *testing.T) {
+	SetBusiness(3, 12)
+	assert.Equal(t, 3.0, testutil.ToFloat64(openPolls))
+	assert.Equal(t, 12.0, testutil.ToFloat64(responsesLastHour))
+}
@@ -2,9 +2,13 @@ package mocker
 
 import (
 	"context"
+	"iter"
+	"time"
 
 	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type MockEventService struct {
@@ -28,13 +32,37 @@ func (m *MockEventService) DeleteEvent(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
-func (m *MockEventService) GetAllEvents(ctx context.Context, filter string) ([]models.Event, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]models.Event), args.Error(1)
+func (m *MockEventService) GetAllEvents(ctx context.Context, title string, opts query.FindOptions) (*query.Page[models.Event], error) {
+	args := m.Called(ctx, title, opts)
+	return args.Get(0).(*query.Page[models.Event]), args.Error(1)
 }
-func (m *MockEventService) UpdateEvent(ctx context.Context, id string, event models.Event) error {
-	args := m.Called(ctx, event)
-	return args.Error(0)
+func (m *MockEventService) SearchEvents(ctx context.Context, search models.EventSearch, opts query.FindOptions) (*query.Page[models.Event], error) {
+	args := m.Called(ctx, search, opts)
+	return args.Get(0).(*query.Page[models.Event]), args.Error(1)
+}
+func (m *MockEventService) UpdateEvent(ctx context.Context, id string, event models.Event) (*models.Event, error) {
+	args := m.Called(ctx, id, event)
+	return args.Get(0).(*models.Event), args.Error(1)
+}
+func (m *MockEventService) FinalizeEvent(ctx context.Context, id string, slotID string) (*models.Event, error) {
+	args := m.Called(ctx, id, slotID)
+	return args.Get(0).(*models.Event), args.Error(1)
+}
+func (m *MockEventService) ShareEvent(ctx context.Context, id string) (*models.Event, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*models.Event), args.Error(1)
+}
+func (m *MockEventService) RestoreEvent(ctx context.Context, id string) (*models.Event, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*models.Event), args.Error(1)
+}
+func (m *MockEventService) GetOccurrences(ctx context.Context, id string, from, to time.Time) ([]models.TimeSlot, error) {
+	args := m.Called(ctx, id, from, to)
+	return args.Get(0).([]models.TimeSlot), args.Error(1)
+}
+func (m *MockEventService) GetEventHistory(ctx context.Context, id string, opts query.FindOptions) (*query.Page[models.AuditEntry], error) {
+	args := m.Called(ctx, id, opts)
+	return args.Get(0).(*query.Page[models.AuditEntry]), args.Error(1)
 }
 func (m *MockAvailabilityService) AddAvailability(ctx context.Context, a models.Availability) (*models.Availability, error) {
 	args := m.Called(ctx, a)
@@ -45,14 +73,68 @@ func (m *MockAvailabilityService) GetAvailabilitiesByEvent(ctx context.Context,
 	args := m.Called(ctx, eventID)
 	return args.Get(0).([]models.Availability), args.Error(1)
 }
+func (m *MockAvailabilityService) ListAvailabilitiesByEvent(ctx context.Context, eventID string, opts query.FindOptions) (*query.Page[models.Availability], error) {
+	args := m.Called(ctx, eventID, opts)
+	return args.Get(0).(*query.Page[models.Availability]), args.Error(1)
+}
+func (m *MockAvailabilityService) StreamAvailabilitiesByEvent(ctx context.Context, eventID string) iter.Seq2[models.Availability, error] {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).(iter.Seq2[models.Availability, error])
+}
+func (m *MockAvailabilityService) GetAvailabilitiesByUser(ctx context.Context, userID string) ([]models.Availability, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.Availability), args.Error(1)
+}
+func (m *MockAvailabilityService) GetAvailabilityMatrix(ctx context.Context, eventID string) (*models.AvailabilityMatrix, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).(*models.AvailabilityMatrix), args.Error(1)
+}
+func (m *MockAvailabilityService) TallySlots(ctx context.Context, eventID string) (*models.SlotTallies, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).(*models.SlotTallies), args.Error(1)
+}
+func (m *MockAvailabilityService) TallySlotsAsOf(ctx context.Context, eventID string, at time.Time) (*models.Event, *models.SlotTallies, error) {
+	args := m.Called(ctx, eventID, at)
+	return args.Get(0).(*models.Event), args.Get(1).(*models.SlotTallies), args.Error(2)
+}
 func (m *MockAvailabilityService) DeleteAvailability(ctx context.Context, eventID string) error {
 	args := m.Called(ctx, eventID)
 	return args.Error(0)
 }
-func (m *MockAvailabilityService) UpdateAvailability(ctx context.Context, id string, avail models.Availability) error {
-	args := m.Called(ctx, avail)
+func (m *MockAvailabilityService) UpdateAvailability(ctx context.Context, id string, avail models.Availability) (*models.Availability, error) {
+	args := m.Called(ctx, id, avail)
+	return args.Get(0).(*models.Availability), args.Error(1)
+}
+
+type MockUserService struct {
+	mock.Mock
+}
+
+func (m *MockUserService) GetProfile(ctx context.Context, userID string) (*models.User, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockUserService) UpdateProfile(ctx context.Context, userID string, profile models.User) (*models.User, error) {
+	args := m.Called(ctx, userID, profile)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *MockUserService) GetProfiles(ctx context.Context, userIDs []string) ([]models.User, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).([]models.User), args.Error(1)
+}
+func (m *MockUserService) RotateFeedToken(ctx context.Context, userID string) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+func (m *MockUserService) RevokeFeedToken(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
 	return args.Error(0)
 }
+func (m *MockUserService) OpenFeed(ctx context.Context, feedToken string) (context.Context, string, error) {
+	args := m.Called(ctx, feedToken)
+	feedCtx, _ := args.Get(0).(context.Context)
+	return feedCtx, args.String(1), args.Error(2)
+}
 
 type MockRepo[T any] struct {
 	mock.Mock
@@ -67,3 +149,94 @@ func (m *MockRepo[T]) GetByID(ctx context.Context, id string) (*T, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*T), args.Error(1)
 }
+
+func (m *MockRepo[T]) UpdateByID(ctx context.Context, id string, update T) error {
+	args := m.Called(ctx, id, update)
+	return args.Error(0)
+}
+
+func (m *MockRepo[T]) DeleteByID(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepo[T]) FindAll(ctx context.Context, filter query.Filter) ([]T, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]T), args.Error(1)
+}
+
+// Stream yields the mocked slice, followed by the mocked error if any.
+func (m *MockRepo[T]) Stream(ctx context.Context, filter query.Filter) iter.Seq2[T, error] {
+	args := m.Called(ctx, filter)
+	docs, _ := args.Get(0).([]T)
+	err := args.Error(1)
+	return func(yield func(T, error) bool) {
+		for _, d := range docs {
+			if !yield(d, nil) {
+				return
+			}
+		}
+		if err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+func (m *MockRepo[T]) FindPage(ctx context.Context, filter query.Filter, opts query.FindOptions) (*query.Page[T], error) {
+	args := m.Called(ctx, filter, opts)
+	return args.Get(0).(*query.Page[T]), args.Error(1)
+}
+
+func (m *MockRepo[T]) CountDocuments(ctx context.Context, filter query.Filter) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo[T]) GetDeletedByID(ctx context.Context, id string) (*T, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*T), args.Error(1)
+}
+
+func (m *MockRepo[T]) RestoreByID(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepo[T]) DeleteMany(ctx context.Context, filter query.Filter) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo[T]) RestoreMany(ctx context.Context, filter query.Filter, since time.Time) (int64, error) {
+	args := m.Called(ctx, filter, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo[T]) PurgeDeleted(ctx context.Context, filter query.Filter, cutoff time.Time) (int64, error) {
+	args := m.Called(ctx, filter, cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockAggregates struct {
+	mock.Mock
+}
+
+func (m *MockAggregates) Matrix(ctx context.Context, eventID primitive.ObjectID) (*models.MatrixCounts, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).(*models.MatrixCounts), args.Error(1)
+}
+
+func (m *MockAggregates) TallySlots(ctx context.Context, eventID primitive.ObjectID) (*models.SlotTallies, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).(*models.SlotTallies), args.Error(1)
+}
+
+type MockRevisionHistory struct {
+	mock.Mock
+}
+
+func (m *MockRevisionHistory) LatestRevisions(ctx context.Context, eventID primitive.ObjectID, at time.Time) ([]models.Revision, error) {
+	args := m.Called(ctx, eventID, at)
+	return args.Get(0).([]models.Revision), args.Error(1)
+}
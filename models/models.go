@@ -7,26 +7,214 @@ import (
 
 type TimeSlot struct {
     ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-    StartTime time.Time          `json:"startTime"`
-    EndTime   time.Time          `json:"endTime"`
+    StartTime time.Time          `bson:"startTime" json:"startTime"`
+    EndTime   time.Time          `bson:"endTime" json:"endTime"`
 }
 
+const (
+    EventStatusOpen      = "open"
+    EventStatusFinalized = "finalized"
+)
+
 type Event struct {
-    ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-    Title         string             `json:"title"`
-    EstimatedMins int                `json:"estimatedMins"`
-    Slots         []TimeSlot         `json:"slots"`
+    ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+    Title         string              `bson:"title" json:"title"`
+    EstimatedMins int                 `bson:"estimatedMins" json:"estimatedMins"`
+    Slots         []TimeSlot          `bson:"slots" json:"slots"`
+    Organizers    []string            `bson:"organizers" json:"organizers"`     // user ids allowed to manage and delete the event
+    CoOrganizers  []string            `bson:"coOrganizers" json:"coOrganizers"` // user ids allowed to manage but not delete the event
+    Status        string              `bson:"status" json:"status"`
+    FinalSlotID   *primitive.ObjectID `bson:"finalSlotId,omitempty" json:"finalSlotId,omitempty"`
+    OrgID         string              `bson:"orgId" json:"orgId"`
+    ShareToken    string              `bson:"shareToken,omitempty" json:"shareToken,omitempty"` // public poll link, see services.GuestService
+    TimeZone      string              `bson:"timeZone,omitempty" json:"timeZone,omitempty"`     // IANA zone of the organizer, "Europe/Berlin"
+    Recurrence    *Recurrence         `bson:"recurrence,omitempty" json:"recurrence,omitempty"` // makes every slot a repeating pattern
+    DeletedAt     *time.Time          `bson:"deletedAt,omitempty" json:"-"`                     // set while the event can still be restored, only through DeleteEvent
+    SchemaVersion int                 `bson:"schemaVersion" json:"-"`
+}
+
+// Recurrence repeats every slot of a standing meeting. Each slot is then a
+// pattern whose start is its first occurrence, and answers apply to the
+// pattern rather than to a single date.
+type Recurrence struct {
+    RRule   string      `bson:"rrule" json:"rrule"`                         // RFC 5545 RRULE value, "FREQ=WEEKLY;BYDAY=TU;COUNT=10"
+    ExDates []time.Time `bson:"exDates,omitempty" json:"exDates,omitempty"` // occurrence starts that are skipped
 }
 
 type Availability struct {
-    ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    EventID       primitive.ObjectID `bson:"eventId" json:"eventId"`
+    UserID        string             `bson:"userId" json:"userId"`
+    SlotID        primitive.ObjectID `bson:"slotId" json:"slotId"`
+    OrgID         string             `bson:"orgId" json:"orgId"`
+    DisplayName   string             `bson:"displayName,omitempty" json:"displayName,omitempty"` // set for guests answering a shared poll
+    DeletedAt     *time.Time         `bson:"deletedAt,omitempty" json:"-"`
+    SchemaVersion int                `bson:"schemaVersion" json:"-"`
+}
+
+// Guest is an unauthenticated participant of a shared poll. Only the hash of
+// their edit token is stored.
+type Guest struct {
+    ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    EventID       primitive.ObjectID `bson:"eventId" json:"eventId"`
+    DisplayName   string             `bson:"displayName" json:"displayName"`
+    TokenHash     string             `bson:"tokenHash" json:"-"`
+    OrgID         string             `bson:"orgId" json:"orgId"`
+    CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+    SchemaVersion int                `bson:"schemaVersion" json:"-"`
+}
+
+// UserID is the availability user id recorded for the guest's answers.
+func (g Guest) UserID() string { return "guest:" + g.ID.Hex() }
+
+// Audit actions, see AuditEntry.
+const (
+    AuditCreated   = "created"
+    AuditUpdated   = "updated"
+    AuditDeleted   = "deleted"
+    AuditFinalized = "finalized"
+    AuditShared    = "shared"
+    AuditRestored  = "restored"
+)
+
+// AuditEntry records one change made to an event or one of its answers. Entries
+// are only ever inserted, see services.AuditLog.
+type AuditEntry struct {
+    ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    EventID       primitive.ObjectID `bson:"eventId" json:"eventId"`
+    Entity        string             `bson:"entity" json:"entity"` // "event" or "availability"
+    EntityID      primitive.ObjectID `bson:"entityId" json:"entityId"`
+    Action        string             `bson:"action" json:"action"`
+    Actor         string             `bson:"actor" json:"actor"` // user id, "guest:<id>" for poll guests
+    At            time.Time          `bson:"at" json:"at"`
+    RequestID     string             `bson:"requestId,omitempty" json:"requestId,omitempty"`
+    Changes       []FieldChange      `bson:"changes,omitempty" json:"changes,omitempty"`
+    OrgID         string             `bson:"orgId" json:"orgId"`
+    SchemaVersion int                `bson:"schemaVersion" json:"-"`
+}
+
+// FieldChange is one top level field that differs, in its JSON form. Before is
+// nil on creation and After on deletion.
+type FieldChange struct {
+    Field  string `bson:"field" json:"field"`
+    Before any    `bson:"before,omitempty" json:"before,omitempty"`
+    After  any    `bson:"after,omitempty" json:"after,omitempty"`
+}
+
+// Revision is the full state of an event or of one of its answers right after
+// a change, see services.RevisionLog. Exactly one of Event and Availability is
+// set, unless the change deleted it.
+type Revision struct {
+    ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    EventID       primitive.ObjectID `bson:"eventId" json:"eventId"`
+    Entity        string             `bson:"entity" json:"entity"` // "event" or "availability"
+    EntityID      primitive.ObjectID `bson:"entityId" json:"entityId"`
+    At            time.Time          `bson:"at" json:"at"`
+    Deleted       bool               `bson:"deleted" json:"deleted"`
+    Event         *Event             `bson:"event,omitempty" json:"event,omitempty"`
+    Availability  *Availability      `bson:"availability,omitempty" json:"availability,omitempty"`
+    OrgID         string             `bson:"orgId" json:"orgId"`
+    SchemaVersion int                `bson:"schemaVersion" json:"-"`
+}
+
+// GetOrgID and SetOrgID let repository.TenantRepository scope documents to an organization.
+func (e *Event) GetOrgID() string { return e.OrgID }
+func (e *Event) SetOrgID(org string) { e.OrgID = org }
+func (a *Availability) GetOrgID() string { return a.OrgID }
+func (a *Availability) SetOrgID(org string) { a.OrgID = org }
+func (g *Guest) GetOrgID() string { return g.OrgID }
+func (g *Guest) SetOrgID(org string) { g.OrgID = org }
+func (e *AuditEntry) GetOrgID() string { return e.OrgID }
+func (e *AuditEntry) SetOrgID(org string) { e.OrgID = org }
+func (r *Revision) GetOrgID() string { return r.OrgID }
+func (r *Revision) SetOrgID(org string) { r.OrgID = org }
+func (u *User) GetOrgID() string { return u.OrgID }
+func (u *User) SetOrgID(org string) { u.OrgID = org }
+
+// SetSchemaVersion lets the repository stamp documents with the schema
+// version they were written in, see repository.Migrations.
+func (e *Event) SetSchemaVersion(v int) { e.SchemaVersion = v }
+func (a *Availability) SetSchemaVersion(v int) { a.SchemaVersion = v }
+func (g *Guest) SetSchemaVersion(v int) { g.SchemaVersion = v }
+func (e *AuditEntry) SetSchemaVersion(v int) { e.SchemaVersion = v }
+func (r *Revision) SetSchemaVersion(v int) { r.SchemaVersion = v }
+func (u *User) SetSchemaVersion(v int) { u.SchemaVersion = v }
+
+// SetDeletedAt marks the models whose deletion is kept for a while and can be
+// undone, see repository.SoftDeletable.
+func (e *Event) SetDeletedAt(t *time.Time) { e.DeletedAt = t }
+func (a *Availability) SetDeletedAt(t *time.Time) { a.DeletedAt = t }
+
+// EventSearch narrows an event listing. Zero fields do not filter, set fields
+// must all match.
+type EventSearch struct {
+    Text      string    // words looked up in the title text index, case-insensitive
+    From      time.Time // an event matches when one of its slots starts in [From, To)
+    To        time.Time
+    Status    string
+    Organizer string // user id, organizer or co-organizer
+}
+
+// AvailabilityMatrix is the users x slots pivot of an event's answers.
+type AvailabilityMatrix struct {
     EventID primitive.ObjectID `json:"eventId"`
-    UserID  string             `json:"userId"`
-    SlotID  primitive.ObjectID `json:"slotId"`
+    Slots   []MatrixSlot       `json:"slots"`
+    Rows    []MatrixRow        `json:"rows"`
+}
+
+type MatrixSlot struct {
+    TimeSlot
+    Total int `json:"total"` // distinct users available
+}
+
+type MatrixRow struct {
+    UserID      string `json:"userId"`
+    DisplayName string `json:"displayName,omitempty"`
+    Available   []bool `json:"available"` // one entry per MatrixSlot, same order
+}
+
+// MatrixCounts groups the answers of an event by user and by slot, the raw
+// material of an AvailabilityMatrix.
+type MatrixCounts struct {
+    Rows   []UserSlots `bson:"rows"`   // sorted by user id
+    Totals []SlotCount `bson:"totals"` // distinct users per slot
+}
+
+type UserSlots struct {
+    UserID      string               `bson:"_id"`
+    DisplayName string               `bson:"displayName"`
+    Slots       []primitive.ObjectID `bson:"slots"`
+}
+
+type SlotCount struct {
+    SlotID primitive.ObjectID `bson:"_id"`
+    Count  int                `bson:"count"`
+}
+
+// SlotTallies counts, per slot, the distinct users available for an event.
+type SlotTallies struct {
+    Slots []SlotTally `json:"slots"` // most popular first
+    Users []string    `json:"users"` // everybody who answered
+}
+
+type SlotTally struct {
+    SlotID primitive.ObjectID `bson:"_id" json:"slotId"`
+    Users  []string           `bson:"users" json:"users"`
+    Count  int                `bson:"count" json:"count"`
 }
 
-type User struct{
-	UserID string `json:"userId"`
-	UserName string `json:"userName"`
+// User is the profile of a user in an organization. A user without one sees
+// times in the zone of each event and is assumed to work 09:00 to 17:00, see
+// services.DefaultProfile.
+type User struct {
+    ID            primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+    UserID        string             `bson:"userId" json:"userId"`
+    UserName      string             `bson:"userName" json:"userName"`
+    TimeZone      string             `bson:"timeZone" json:"timeZone"`   // IANA zone, "America/Sao_Paulo"
+    WorkStart     string             `bson:"workStart" json:"workStart"` // local "15:04" the working day starts
+    WorkEnd       string             `bson:"workEnd" json:"workEnd"`
+    FeedTokenHash string             `bson:"feedTokenHash,omitempty" json:"-"` // of the calendar feed token, see services.UserService
+    OrgID         string             `bson:"orgId" json:"orgId"`
+    SchemaVersion int                `bson:"schemaVersion" json:"-"`
 }
 
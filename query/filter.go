@@ -0,0 +1,89 @@
+package query
+
+// Op is the kind of condition a Filter expresses.
+type Op int
+
+const (
+	OpAll   Op = iota // matches every document, the zero Filter
+	OpEq              // Field equals Value
+	OpIn              // Field equals one of Values
+	OpRange           // From <= Field < To, a nil bound is open
+	OpAnd             // every one of Filters
+	OpOr              // at least one of Filters
+	OpText            // Value (a string) matches the backend's full-text index
+	OpElem            // some element of the list Field matches Filters[0]
+)
+
+// Filter is a storage independent condition on named document fields. Fields
+// are the JSON names of the model, dotted for nested documents ("slots.startTime");
+// fields hidden from JSON are named after the Go field ("tokenHash"). Each
+// repository backend translates a Filter to its own query language.
+//
+// Build filters with the constructors below; the zero Filter matches everything.
+type Filter struct {
+	Op      Op
+	Field   string
+	Value   any
+	Values  []any
+	From    any
+	To      any
+	Filters []Filter
+}
+
+func Eq(field string, value any) Filter {
+	return Filter{Op: OpEq, Field: field, Value: value}
+}
+
+func In[V any](field string, values ...V) Filter {
+	f := Filter{Op: OpIn, Field: field, Values: make([]any, 0, len(values))}
+	for _, v := range values {
+		f.Values = append(f.Values, v)
+	}
+	return f
+}
+
+// Range matches from <= field < to. Pass nil to leave a bound open.
+func Range(field string, from, to any) Filter {
+	return Filter{Op: OpRange, Field: field, From: from, To: to}
+}
+
+// Text matches documents whose text indexed fields contain the given words.
+func Text(words string) Filter {
+	return Filter{Op: OpText, Value: words}
+}
+
+// Elem matches documents where at least one element of the list field
+// satisfies f on its own. f names fields relative to the element.
+func Elem(field string, f Filter) Filter {
+	return Filter{Op: OpElem, Field: field, Filters: []Filter{f}}
+}
+
+// And matches documents satisfying every filter. Nested Ands are flattened
+// and match-all filters dropped.
+func And(filters ...Filter) Filter {
+	var and []Filter
+	for _, f := range filters {
+		switch f.Op {
+		case OpAll:
+		case OpAnd:
+			and = append(and, f.Filters...)
+		default:
+			and = append(and, f)
+		}
+	}
+	switch len(and) {
+	case 0:
+		return Filter{}
+	case 1:
+		return and[0]
+	}
+	return Filter{Op: OpAnd, Filters: and}
+}
+
+// Or matches documents satisfying at least one filter.
+func Or(filters ...Filter) Filter {
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	return Filter{Op: OpOr, Filters: filters}
+}
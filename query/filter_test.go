@@ -0,0 +1,13 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAndFlattens(t *testing.T) {
+	a, b := Eq("title", "x"), Eq("status", "open")
+	assert.Equal(t, a, And(Filter{}, a))
+	assert.Equal(t, Filter{Op: OpAnd, Filters: []Filter{a, b}}, And(And(a), And(b, Filter{})))
+}
@@ -0,0 +1,65 @@
+// Package query holds the storage independent paging, sorting and projection
+// options accepted by the list endpoints.
+package query
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid page cursor")
+	ErrUnknownField  = errors.New("unknown field")
+)
+
+// SortField orders a page by one API field name.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// FindOptions controls paging, ordering and projection of a list. Field
+// names are the JSON names of the listed model.
+type FindOptions struct {
+	Limit  int64
+	After  string // Page.Next of the previous page
+	Sort   []SortField
+	Fields []string
+}
+
+// Page is one page of results. Next is empty on the last page.
+type Page[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next,omitempty"`
+	Total int64  `json:"total"`
+}
+
+// ParseSort parses "title,-status" into sort fields, "-" meaning descending.
+func ParseSort(raw string) []SortField {
+	var sort []SortField
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		desc := strings.HasPrefix(f, "-")
+		sort = append(sort, SortField{Field: strings.TrimLeft(f, "+-"), Desc: desc})
+	}
+	return sort
+}
+
+// ParseFields parses a comma separated projection list.
+func ParseFields(raw string) []string {
+	var fields []string
+	for _, f := range strings.Split(raw, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
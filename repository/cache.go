@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// CachedRepository keeps recently read documents in an in-process LRU so
+// repeated GetByID calls skip the database. Entries are dropped when the
+// document is updated or deleted through this repository and expire after
+// the TTL, which bounds how stale a copy changed by another instance can be.
+// Every other call goes straight to the wrapped repository.
+type CachedRepository[T any] struct {
+	inner MongoRepository[T]
+	docs  *expirable.LRU[string, T]
+}
+
+func NewCachedRepository[T any](inner MongoRepository[T], size int, ttl time.Duration) *CachedRepository[T] {
+	return &CachedRepository[T]{inner: inner, docs: expirable.NewLRU[string, T](size, nil, ttl)}
+}
+
+// clone deep-copies doc through BSON, the form it was read in, so neither
+// the cache nor its callers share slices or pointers with the other.
+func clone[T any](doc T) (T, error) {
+	var copied T
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return copied, err
+	}
+	err = bson.Unmarshal(raw, &copied)
+	return copied, err
+}
+
+// GetByID returns a deep copy of the cached document, callers may modify it
+// freely.
+func (r *CachedRepository[T]) GetByID(ctx context.Context, id string) (*T, error) {
+	if doc, ok := r.docs.Get(id); ok {
+		copied, err := clone(doc)
+		if err != nil {
+			return nil, err
+		}
+		return &copied, nil
+	}
+	doc, err := r.inner.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cached, err := clone(*doc); err == nil {
+		r.docs.Add(id, cached)
+	}
+	return doc, nil
+}
+
+func (r *CachedRepository[T]) UpdateByID(ctx context.Context, id string, update T) error {
+	defer r.docs.Remove(id) // also when the update fails half way
+	return r.inner.UpdateByID(ctx, id, update)
+}
+
+func (r *CachedRepository[T]) DeleteByID(ctx context.Context, id string) error {
+	defer r.docs.Remove(id)
+	return r.inner.DeleteByID(ctx, id)
+}
+
+func (r *CachedRepository[T]) Insert(ctx context.Context, doc T) (*T, error) {
+	return r.inner.Insert(ctx, doc)
+}
+
+func (r *CachedRepository[T]) FindAll(ctx context.Context, filter query.Filter) ([]T, error) {
+	return r.inner.FindAll(ctx, filter)
+}
+
+func (r *CachedRepository[T]) Stream(ctx context.Context, filter query.Filter) iter.Seq2[T, error] {
+	return r.inner.Stream(ctx, filter)
+}
+
+func (r *CachedRepository[T]) FindPage(ctx context.Context, filter query.Filter, opts query.FindOptions) (*query.Page[T], error) {
+	return r.inner.FindPage(ctx, filter, opts)
+}
+
+func (r *CachedRepository[T]) CountDocuments(ctx context.Context, filter query.Filter) (int64, error) {
+	return r.inner.CountDocuments(ctx, filter)
+}
+
+func (r *CachedRepository[T]) GetDeletedByID(ctx context.Context, id string) (*T, error) {
+	return r.inner.GetDeletedByID(ctx, id)
+}
+
+func (r *CachedRepository[T]) RestoreByID(ctx context.Context, id string) error {
+	defer r.docs.Remove(id)
+	return r.inner.RestoreByID(ctx, id)
+}
+
+// DeleteMany cannot tell which cached documents it deletes, so it drops them all.
+func (r *CachedRepository[T]) DeleteMany(ctx context.Context, filter query.Filter) (int64, error) {
+	defer r.docs.Purge()
+	return r.inner.DeleteMany(ctx, filter)
+}
+
+func (r *CachedRepository[T]) RestoreMany(ctx context.Context, filter query.Filter, since time.Time) (int64, error) {
+	return r.inner.RestoreMany(ctx, filter, since) // deleted documents are never cached
+}
+
+func (r *CachedRepository[T]) PurgeDeleted(ctx context.Context, filter query.Filter, cutoff time.Time) (int64, error) {
+	return r.inner.PurgeDeleted(ctx, filter, cutoff)
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/mocker"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCachedRepository(t *testing.T) {
+	inner := new(mocker.MockRepo[models.Event])
+	repo := NewCachedRepository[models.Event](inner, 10, time.Minute)
+	ctx := context.Background()
+	id := primitive.NewObjectID()
+
+	slotID := primitive.NewObjectID()
+	inner.On("GetByID", mock.Anything, id.Hex()).Return(&models.Event{ID: id, Title: "retro", Slots: []models.TimeSlot{{ID: slotID}}}, nil)
+	inner.On("UpdateByID", mock.Anything, id.Hex(), mock.Anything).Return(nil)
+	inner.On("DeleteByID", mock.Anything, id.Hex()).Return(nil)
+
+	first, err := repo.GetByID(ctx, id.Hex())
+	assert.NoError(t, err)
+	first.Title = "changed by the caller"
+	first.Slots[0].ID = primitive.NewObjectID()
+	second, err := repo.GetByID(ctx, id.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, "retro", second.Title)
+	assert.Equal(t, slotID, second.Slots[0].ID, "slices are copied too")
+	second.Slots[0].ID = primitive.NewObjectID()
+	third, _ := repo.GetByID(ctx, id.Hex())
+	assert.Equal(t, slotID, third.Slots[0].ID)
+	inner.AssertNumberOfCalls(t, "GetByID", 1)
+
+	assert.NoError(t, repo.UpdateByID(ctx, id.Hex(), models.Event{}))
+	_, _ = repo.GetByID(ctx, id.Hex())
+	inner.AssertNumberOfCalls(t, "GetByID", 2)
+
+	assert.NoError(t, repo.DeleteByID(ctx, id.Hex()))
+	_, _ = repo.GetByID(ctx, id.Hex())
+	inner.AssertNumberOfCalls(t, "GetByID", 3)
+}
+
+func TestCachedRepositoryExpires(t *testing.T) {
+	inner := new(mocker.MockRepo[models.Event])
+	repo := NewCachedRepository[models.Event](inner, 10, 10*time.Millisecond)
+	id := primitive.NewObjectID()
+	inner.On("GetByID", mock.Anything, id.Hex()).Return(&models.Event{ID: id}, nil)
+
+	_, _ = repo.GetByID(context.Background(), id.Hex())
+	time.Sleep(20 * time.Millisecond)
+	_, _ = repo.GetByID(context.Background(), id.Hex())
+	inner.AssertNumberOfCalls(t, "GetByID", 2)
+}
@@ -2,20 +2,34 @@ package repository
 
 import (
 	"context"
+	"iter"
+	"time"
 
+	"github.com/chetanugale/scheduling-system/query"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// MongoRepository defines generic CRUD operations.
+// MongoRepository defines generic CRUD operations. Filters are built with
+// package query and translated to Mongo by the implementation.
 type MongoRepository[T any] interface {
 	Insert(ctx context.Context, doc T) (*T, error)
 	GetByID(ctx context.Context, id string) (*T, error)
 	UpdateByID(ctx context.Context, id string, update T) error
 	DeleteByID(ctx context.Context, id string) error
-	FindAll(ctx context.Context, filter any) ([]T, error)
-	CountDocuments(ctx context.Context, filter any) (int64, error)
+	FindAll(ctx context.Context, filter query.Filter) ([]T, error)
+	Stream(ctx context.Context, filter query.Filter) iter.Seq2[T, error]
+	FindPage(ctx context.Context, filter query.Filter, opts query.FindOptions) (*query.Page[T], error)
+	CountDocuments(ctx context.Context, filter query.Filter) (int64, error)
+
+	// Soft deletion, see SoftDeletable. Models without it have nothing to
+	// restore or purge.
+	GetDeletedByID(ctx context.Context, id string) (*T, error)
+	RestoreByID(ctx context.Context, id string) error
+	DeleteMany(ctx context.Context, filter query.Filter) (int64, error)
+	RestoreMany(ctx context.Context, filter query.Filter, since time.Time) (int64, error)
+	PurgeDeleted(ctx context.Context, filter query.Filter, cutoff time.Time) (int64, error)
 }
 
 // MongoRepositoryImpl provides a generic implementation for any model T.
@@ -27,8 +41,12 @@ func NewMongoRepository[T any](coll *mongo.Collection) MongoRepository[T] {
 	return &MongoRepositoryImpl[T]{collection: coll}
 }
 
-func (r *MongoRepositoryImpl[T]) CountDocuments(ctx context.Context, filter any) (int64, error) {
-	count, err := r.collection.CountDocuments(ctx, filter)
+func (r *MongoRepositoryImpl[T]) CountDocuments(ctx context.Context, filter query.Filter) (int64, error) {
+	match, err := toBSON[T](filter)
+	if err != nil {
+		return 0, err
+	}
+	count, err := r.collection.CountDocuments(ctx, live[T](match))
 	if err != nil {
 		return 0, err
 	}
@@ -36,6 +54,7 @@ func (r *MongoRepositoryImpl[T]) CountDocuments(ctx context.Context, filter any)
 }
 
 func (r *MongoRepositoryImpl[T]) Insert(ctx context.Context, doc T) (*T, error) {
+	stamp(&doc)
 	_, err := r.collection.InsertOne(ctx, doc) // TODO : optimize - use response to validate data
 	if err != nil {
 		return nil, err
@@ -48,7 +67,7 @@ func (r *MongoRepositoryImpl[T]) GetByID(ctx context.Context, id string) (*T, er
 	if err != nil {
 		return nil, err
 	}
-	filter := bson.M{"_id": objID}
+	filter := live[T](bson.M{"_id": objID})
 
 	var result T
 	err = r.collection.FindOne(ctx, filter).Decode(&result)
@@ -63,7 +82,8 @@ func (r *MongoRepositoryImpl[T]) UpdateByID(ctx context.Context, id string, upda
 	if err != nil {
 		return err
 	}
-	_, err = r.collection.ReplaceOne(ctx, bson.M{"_id": objID}, update)
+	stamp(&update)
+	_, err = r.collection.ReplaceOne(ctx, live[T](bson.M{"_id": objID}), update)
 	return err
 }
 
@@ -72,26 +92,54 @@ func (r *MongoRepositoryImpl[T]) DeleteByID(ctx context.Context, id string) erro
 	if err != nil {
 		return err
 	}
+	if softDeletes[T]() {
+		_, err = r.collection.UpdateOne(ctx, live[T](bson.M{"_id": objID}), bson.M{"$set": bson.M{deletedField: time.Now()}})
+		return err
+	}
 	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": objID})
 	return err
 }
 
-func (r *MongoRepositoryImpl[T]) FindAll(ctx context.Context, filter any) ([]T, error) {
-	cursor, err := r.collection.Find(ctx, filter)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
+func (r *MongoRepositoryImpl[T]) FindAll(ctx context.Context, filter query.Filter) ([]T, error) {
 	var results []T
-	for cursor.Next(ctx) {
-		var elem T
-		if err := cursor.Decode(&elem); err != nil {
+	for elem, err := range r.Stream(ctx, filter) {
+		if err != nil {
 			return nil, err
 		}
 		results = append(results, elem)
 	}
-	if err := cursor.Err(); err != nil {
-		return nil, err
-	}
 	return results, nil
 }
+
+// Stream yields the documents matching filter one at a time as the cursor
+// reads them. The first error is yielded with a zero T and ends the sequence;
+// breaking out of the loop closes the cursor.
+func (r *MongoRepositoryImpl[T]) Stream(ctx context.Context, filter query.Filter) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		match, err := toBSON[T](filter)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		cursor, err := r.collection.Find(ctx, live[T](match))
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer cursor.Close(ctx)
+		for cursor.Next(ctx) {
+			var elem T
+			if err := cursor.Decode(&elem); err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(elem, nil) {
+				return
+			}
+		}
+		if err := cursor.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// jsonName is the API name of f, or "" when f is hidden from JSON.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// bsonKey is the key Mongo stores f under: the bson tag name, or the
+// lowercased Go name without one.
+func bsonKey(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("bson"), ",")
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+// FieldMap maps the top level JSON field names of T to the keys Mongo stores
+// them under. Fields hidden from JSON or bson are left out.
+func FieldMap[T any]() map[string]string {
+	fields := map[string]string{}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || jsonName(f) == "" || bsonKey(f) == "-" {
+			continue
+		}
+		fields[jsonName(f)] = bsonKey(f)
+	}
+	return fields
+}
+
+// SortFields maps the JSON names of the top level fields of T a page may be
+// sorted by to their Mongo keys: scalars that every document stores. Lists,
+// nested documents and omitempty fields are left out, a missing or multi
+// valued key has no single place in the order and cannot be carried in a
+// page cursor.
+func SortFields[T any]() map[string]string {
+	fields := map[string]string{}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || jsonName(f) == "" || bsonKey(f) == "-" || !scalar(f.Type) {
+			continue
+		}
+		key := bsonKey(f)
+		// Mongo always sets _id
+		if _, opts, _ := strings.Cut(f.Tag.Get("bson"), ","); key != "_id" && strings.Contains(opts, "omitempty") {
+			continue
+		}
+		fields[jsonName(f)] = key
+	}
+	return fields
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+)
+
+// scalar reports whether values of t are stored as a single bson value.
+func scalar(t reflect.Type) bool {
+	if t == timeType || t == objectIDType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// field finds the struct field of t a filter calls name: by JSON name, else
+// by Go name ignoring case for fields hidden from JSON.
+func field(t reflect.Type, name string) (reflect.StructField, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	var hidden reflect.StructField
+	found := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || bsonKey(f) == "-" {
+			continue
+		}
+		if jsonName(f) == name {
+			return f, true
+		}
+		if strings.EqualFold(f.Name, name) {
+			hidden, found = f, true
+		}
+	}
+	return hidden, found
+}
+
+// bsonPath translates a dotted filter field of t into the Mongo key path and
+// returns the type found at its end, list element type for lists.
+func bsonPath(t reflect.Type, path string) (string, reflect.Type, error) {
+	var keys []string
+	for _, name := range strings.Split(path, ".") {
+		f, ok := field(t, name)
+		if !ok {
+			return "", nil, fmt.Errorf("%w %q", query.ErrUnknownField, path)
+		}
+		keys = append(keys, bsonKey(f))
+		t = f.Type
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = t.Elem()
+		}
+	}
+	return strings.Join(keys, "."), t, nil
+}
+
+// toBSON translates f into a Mongo filter on documents of type T.
+func toBSON[T any](f query.Filter) (bson.M, error) {
+	return translate(reflect.TypeOf((*T)(nil)).Elem(), f)
+}
+
+func translate(t reflect.Type, f query.Filter) (bson.M, error) {
+	switch f.Op {
+	case query.OpAll:
+		return bson.M{}, nil
+	case query.OpText:
+		return bson.M{"$text": bson.M{"$search": f.Value}}, nil
+	case query.OpAnd, query.OpOr:
+		list := bson.A{}
+		for _, sub := range f.Filters {
+			m, err := translate(t, sub)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, m)
+		}
+		if f.Op == query.OpAnd {
+			return bson.M{"$and": list}, nil
+		}
+		return bson.M{"$or": list}, nil
+	}
+
+	key, elem, err := bsonPath(t, f.Field)
+	if err != nil {
+		return nil, err
+	}
+	switch f.Op {
+	case query.OpEq:
+		return bson.M{key: f.Value}, nil
+	case query.OpIn:
+		return bson.M{key: bson.M{"$in": bson.A(f.Values)}}, nil
+	case query.OpRange:
+		cond := bson.M{}
+		if f.From != nil {
+			cond["$gte"] = f.From
+		}
+		if f.To != nil {
+			cond["$lt"] = f.To
+		}
+		return bson.M{key: cond}, nil
+	case query.OpElem:
+		if len(f.Filters) != 1 {
+			return nil, fmt.Errorf("element filter on %q needs one condition", f.Field)
+		}
+		inner, err := translate(elem, f.Filters[0])
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{key: bson.M{"$elemMatch": inner}}, nil
+	}
+	return nil, fmt.Errorf("unsupported filter op %d", f.Op)
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestToBSON(t *testing.T) {
+	from := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+	id := primitive.NewObjectID()
+
+	m, err := toBSON[models.Event](query.And(
+		query.Text("retro"),
+		query.Elem("slots", query.Range("startTime", from, nil)),
+		query.Or(query.Eq("organizers", "alice"), query.Eq("coOrganizers", "alice")),
+		query.In("id", id),
+	))
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"$and": bson.A{
+		bson.M{"$text": bson.M{"$search": "retro"}},
+		bson.M{"slots": bson.M{"$elemMatch": bson.M{"startTime": bson.M{"$gte": from}}}},
+		bson.M{"$or": bson.A{bson.M{"organizers": "alice"}, bson.M{"coOrganizers": "alice"}}},
+		bson.M{"_id": bson.M{"$in": bson.A{id}}},
+	}}, m)
+
+	m, err = toBSON[models.Event](query.Eq("slots.startTime", from))
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"slots.startTime": from}, m)
+
+	m, err = toBSON[models.Guest](query.Eq("tokenHash", "abc")) // hidden from JSON, still filterable
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"tokenHash": "abc"}, m)
+
+	m, err = toBSON[models.Event](query.Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{}, m)
+
+	_, err = toBSON[models.Event](query.Eq("slots.nope", 1))
+	assert.ErrorIs(t, err, query.ErrUnknownField)
+}
+
+func TestLiveHidesSoftDeleted(t *testing.T) {
+	match := bson.M{"title": "retro"}
+	assert.Equal(t, bson.M{"$and": bson.A{match, bson.M{"deletedAt": nil}}}, live[models.Event](match))
+	assert.Equal(t, match, live[models.Guest](match)) // guests are deleted for good
+}
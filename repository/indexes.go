@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/chetanugale/scheduling-system/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// baseIndexes are created by migration 2, per collection. New indexes go in a
+// new migration, databases that already ran this one would never see them.
+func baseIndexes(colls config.Collections) map[string][]mongo.IndexModel {
+	return map[string][]mongo.IndexModel{
+		colls.Events: {
+			// backs the title search of services.EventSearch, a collection can only have one text index
+			{Keys: bson.D{{Key: "title", Value: "text"}}, Options: options.Index().SetName("title_text")},
+			{Keys: bson.D{{Key: "orgId", Value: 1}, {Key: "_id", Value: 1}}},
+			{Keys: bson.D{{Key: "shareToken", Value: 1}}, Options: options.Index().SetSparse(true)},
+		},
+		colls.Availability: {
+			{Keys: bson.D{{Key: "orgId", Value: 1}, {Key: "eventId", Value: 1}, {Key: "userId", Value: 1}}},
+			{Keys: bson.D{{Key: "orgId", Value: 1}, {Key: "userId", Value: 1}}},
+		},
+		colls.Guests: {
+			{Keys: bson.D{{Key: "orgId", Value: 1}, {Key: "eventId", Value: 1}, {Key: "tokenHash", Value: 1}}},
+		},
+	}
+}
+
+// auditIndexes are created by migration 3.
+func auditIndexes(colls config.Collections) map[string][]mongo.IndexModel {
+	return map[string][]mongo.IndexModel{
+		colls.Audit: {
+			// history of an event, oldest first
+			{Keys: bson.D{{Key: "orgId", Value: 1}, {Key: "eventId", Value: 1}, {Key: "_id", Value: 1}}},
+		},
+	}
+}
+
+// deletedIndexes are created by migration 4. They are sparse, only the few
+// soft-deleted documents waiting to be purged have the field.
+func deletedIndexes(colls config.Collections) map[string][]mongo.IndexModel {
+	deleted := mongo.IndexModel{Keys: bson.D{{Key: deletedField, Value: 1}}, Options: options.Index().SetSparse(true)}
+	return map[string][]mongo.IndexModel{
+		colls.Events:       {deleted},
+		colls.Availability: {deleted},
+	}
+}
+
+// revisionIndexes are created by migration 5.
+func revisionIndexes(colls config.Collections) map[string][]mongo.IndexModel {
+	return map[string][]mongo.IndexModel{
+		colls.Revisions: {
+			// replay of an event as of a time
+			{Keys: bson.D{{Key: "orgId", Value: 1}, {Key: "eventId", Value: 1}, {Key: "at", Value: 1}}},
+			// one baseline per entity, see seedRevisions
+			{Keys: bson.D{{Key: "entityId", Value: 1}}},
+		},
+	}
+}
+
+// userIndexes are created by migration 6.
+func userIndexes(colls config.Collections) map[string][]mongo.IndexModel {
+	return map[string][]mongo.IndexModel{
+		colls.Users: {
+			// one profile per user and organization
+			{Keys: bson.D{{Key: "orgId", Value: 1}, {Key: "userId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+	}
+}
+
+// feedIndexes are created by migration 7.
+func feedIndexes(colls config.Collections) map[string][]mongo.IndexModel {
+	return map[string][]mongo.IndexModel{
+		colls.Users: {
+			// feed tokens are looked up across organizations
+			{Keys: bson.D{{Key: "feedTokenHash", Value: 1}}, Options: options.Index().SetSparse(true)},
+		},
+	}
+}
+
+// EnsureIndexes creates indexes on coll. Creating an index that already
+// exists with the same definition is a no-op.
+func EnsureIndexes(ctx context.Context, coll *mongo.Collection, indexes []mongo.IndexModel) error {
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/metrics"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/chetanugale/scheduling-system/tracing"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// InstrumentedRepository records the latency and failures of every call to
+// the wrapped repository in package metrics and traces it as a child span of
+// the caller's, labelled with the collection. Wrap the Mongo repository
+// directly so cache hits are neither counted nor traced.
+type InstrumentedRepository[T any] struct {
+	inner      MongoRepository[T]
+	collection string
+}
+
+func NewInstrumentedRepository[T any](inner MongoRepository[T], collection string) *InstrumentedRepository[T] {
+	return &InstrumentedRepository[T]{inner: inner, collection: collection}
+}
+
+func (r *InstrumentedRepository[T]) begin(ctx context.Context, op string) (context.Context, func(error)) {
+	return begin(ctx, r.collection, op)
+}
+
+// begin starts timing and tracing op on collection. Call the returned
+// function with the outcome once the call is over. A missing document is an
+// answer, not a failure of the database.
+func begin(ctx context.Context, collection, op string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "mongo."+collection+"."+op,
+		attribute.String("db.system", "mongodb"),
+		attribute.String("db.collection.name", collection),
+		attribute.String("db.operation.name", op),
+	)
+	return ctx, func(err error) {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			err = nil
+		}
+		metrics.ObserveRepo(collection, op, start, err)
+		tracing.End(span, err)
+	}
+}
+
+func (r *InstrumentedRepository[T]) Insert(ctx context.Context, doc T) (*T, error) {
+	ctx, end := r.begin(ctx, "Insert")
+	out, err := r.inner.Insert(ctx, doc)
+	end(err)
+	return out, err
+}
+
+func (r *InstrumentedRepository[T]) GetByID(ctx context.Context, id string) (*T, error) {
+	ctx, end := r.begin(ctx, "GetByID")
+	doc, err := r.inner.GetByID(ctx, id)
+	end(err)
+	return doc, err
+}
+
+func (r *InstrumentedRepository[T]) UpdateByID(ctx context.Context, id string, update T) error {
+	ctx, end := r.begin(ctx, "UpdateByID")
+	err := r.inner.UpdateByID(ctx, id, update)
+	end(err)
+	return err
+}
+
+func (r *InstrumentedRepository[T]) DeleteByID(ctx context.Context, id string) error {
+	ctx, end := r.begin(ctx, "DeleteByID")
+	err := r.inner.DeleteByID(ctx, id)
+	end(err)
+	return err
+}
+
+func (r *InstrumentedRepository[T]) FindAll(ctx context.Context, filter query.Filter) ([]T, error) {
+	ctx, end := r.begin(ctx, "FindAll")
+	docs, err := r.inner.FindAll(ctx, filter)
+	end(err)
+	return docs, err
+}
+
+// Stream is timed until the caller stops iterating, including the time the
+// caller spends on each document.
+func (r *InstrumentedRepository[T]) Stream(ctx context.Context, filter query.Filter) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		ctx, end := r.begin(ctx, "Stream")
+		var failed error
+		defer func() { end(failed) }()
+		for doc, err := range r.inner.Stream(ctx, filter) {
+			if err != nil {
+				failed = err
+			}
+			if !yield(doc, err) {
+				return
+			}
+		}
+	}
+}
+
+func (r *InstrumentedRepository[T]) FindPage(ctx context.Context, filter query.Filter, opts query.FindOptions) (*query.Page[T], error) {
+	ctx, end := r.begin(ctx, "FindPage")
+	page, err := r.inner.FindPage(ctx, filter, opts)
+	end(err)
+	return page, err
+}
+
+func (r *InstrumentedRepository[T]) CountDocuments(ctx context.Context, filter query.Filter) (int64, error) {
+	ctx, end := r.begin(ctx, "CountDocuments")
+	n, err := r.inner.CountDocuments(ctx, filter)
+	end(err)
+	return n, err
+}
+
+func (r *InstrumentedRepository[T]) GetDeletedByID(ctx context.Context, id string) (*T, error) {
+	ctx, end := r.begin(ctx, "GetDeletedByID")
+	doc, err := r.inner.GetDeletedByID(ctx, id)
+	end(err)
+	return doc, err
+}
+
+func (r *InstrumentedRepository[T]) RestoreByID(ctx context.Context, id string) error {
+	ctx, end := r.begin(ctx, "RestoreByID")
+	err := r.inner.RestoreByID(ctx, id)
+	end(err)
+	return err
+}
+
+func (r *InstrumentedRepository[T]) DeleteMany(ctx context.Context, filter query.Filter) (int64, error) {
+	ctx, end := r.begin(ctx, "DeleteMany")
+	n, err := r.inner.DeleteMany(ctx, filter)
+	end(err)
+	return n, err
+}
+
+func (r *InstrumentedRepository[T]) RestoreMany(ctx context.Context, filter query.Filter, since time.Time) (int64, error) {
+	ctx, end := r.begin(ctx, "RestoreMany")
+	n, err := r.inner.RestoreMany(ctx, filter, since)
+	end(err)
+	return n, err
+}
+
+func (r *InstrumentedRepository[T]) PurgeDeleted(ctx context.Context, filter query.Filter, cutoff time.Time) (int64, error) {
+	ctx, end := r.begin(ctx, "PurgeDeleted")
+	n, err := r.inner.PurgeDeleted(ctx, filter, cutoff)
+	end(err)
+	return n, err
+}
+
+// InstrumentedAvailabilityAggregates measures and traces the aggregations like
+// InstrumentedRepository does the CRUD calls.
+type InstrumentedAvailabilityAggregates struct {
+	inner      AvailabilityAggregates
+	collection string
+}
+
+func NewInstrumentedAvailabilityAggregates(inner AvailabilityAggregates, collection string) *InstrumentedAvailabilityAggregates {
+	return &InstrumentedAvailabilityAggregates{inner: inner, collection: collection}
+}
+
+func (r *InstrumentedAvailabilityAggregates) Matrix(ctx context.Context, eventID primitive.ObjectID) (*models.MatrixCounts, error) {
+	ctx, end := begin(ctx, r.collection, "Matrix")
+	counts, err := r.inner.Matrix(ctx, eventID)
+	end(err)
+	return counts, err
+}
+
+func (r *InstrumentedAvailabilityAggregates) TallySlots(ctx context.Context, eventID primitive.ObjectID) (*models.SlotTallies, error) {
+	ctx, end := begin(ctx, r.collection, "TallySlots")
+	tallies, err := r.inner.TallySlots(ctx, eventID)
+	end(err)
+	return tallies, err
+}
+
+// InstrumentedRevisionHistory measures and traces the reads of the revision log.
+type InstrumentedRevisionHistory struct {
+	inner      RevisionHistory
+	collection string
+}
+
+func NewInstrumentedRevisionHistory(inner RevisionHistory, collection string) *InstrumentedRevisionHistory {
+	return &InstrumentedRevisionHistory{inner: inner, collection: collection}
+}
+
+func (r *InstrumentedRevisionHistory) LatestRevisions(ctx context.Context, eventID primitive.ObjectID, at time.Time) ([]models.Revision, error) {
+	ctx, end := begin(ctx, r.collection, "LatestRevisions")
+	revs, err := r.inner.LatestRevisions(ctx, eventID, at)
+	end(err)
+	return revs, err
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chetanugale/scheduling-system/metrics"
+	"github.com/chetanugale/scheduling-system/mocker"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestInstrumentedRepository(t *testing.T) {
+	inner := new(mocker.MockRepo[models.Event])
+	repo := NewInstrumentedRepository[models.Event](inner, "instrumented_events")
+	ctx := context.Background()
+
+	inner.On("GetByID", mock.Anything, "missing").Return((*models.Event)(nil), mongo.ErrNoDocuments)
+	inner.On("UpdateByID", mock.Anything, "broken", mock.Anything).Return(errors.New("timeout"))
+
+	_, err := repo.GetByID(ctx, "missing")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	assert.Error(t, repo.UpdateByID(ctx, "broken", models.Event{}))
+
+	resp := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(resp, httptest.NewRequest("GET", "/metrics", nil))
+	body := resp.Body.String()
+	assert.Contains(t, body, `scheduling_repository_operation_duration_seconds_count{collection="instrumented_events",op="GetByID"} 1`)
+	assert.Contains(t, body, `scheduling_repository_errors_total{collection="instrumented_events",op="UpdateByID"} 1`)
+	assert.NotContains(t, body, `scheduling_repository_errors_total{collection="instrumented_events",op="GetByID"}`, "missing documents are not failures")
+}
+
+func TestInstrumentedAggregates(t *testing.T) {
+	inner := new(mocker.MockAggregates)
+	aggregates := NewInstrumentedAvailabilityAggregates(inner, "instrumented_availabilities")
+	eventID := primitive.NewObjectID()
+
+	inner.On("TallySlots", mock.Anything, eventID).Return((*models.SlotTallies)(nil), errors.New("timeout"))
+	_, err := aggregates.TallySlots(context.Background(), eventID)
+	assert.Error(t, err)
+
+	resp := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(resp, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, resp.Body.String(), `scheduling_repository_errors_total{collection="instrumented_availabilities",op="TallySlots"} 1`)
+}
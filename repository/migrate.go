@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SchemaVersion is the document layout written by this code. Bump it together
+// with a migration that rewrites older documents.
+const SchemaVersion = 1
+
+// Versioned documents record the schema version they were written in.
+type Versioned interface {
+	SetSchemaVersion(v int)
+}
+
+// stamp sets the current schema version on doc if its model is versioned.
+func stamp[T any](doc *T) {
+	if v, ok := any(doc).(Versioned); ok {
+		v.SetSchemaVersion(SchemaVersion)
+	}
+}
+
+// Migration is one step of the database schema. Up must be idempotent: a
+// crash between Up and recording it runs it again on the next start.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database, colls config.Collections) error
+}
+
+// Migrations is the schema history, oldest first. Append only.
+var Migrations = []Migration{
+	{Version: 1, Name: "explicit bson field names", Up: renameFields},
+	{Version: 2, Name: "indexes", Up: indexesOf(baseIndexes)},
+	{Version: 3, Name: "audit indexes", Up: indexesOf(auditIndexes)},
+	{Version: 4, Name: "soft delete indexes", Up: indexesOf(deletedIndexes)},
+	{Version: 5, Name: "revision baseline", Up: seedRevisions},
+	{Version: 6, Name: "user profile indexes", Up: indexesOf(userIndexes)},
+	{Version: 7, Name: "calendar feed token index", Up: indexesOf(feedIndexes)},
+}
+
+// migrationLog records which migrations a database has applied.
+type migrationLog interface {
+	applied(ctx context.Context) (map[int]bool, error)
+	record(ctx context.Context, m Migration) error
+}
+
+// mongoMigrationLog keeps one document per applied migration, keyed by version.
+type mongoMigrationLog struct {
+	coll *mongo.Collection
+}
+
+type appliedMigration struct {
+	Version   int       `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"appliedAt"`
+}
+
+func (l mongoMigrationLog) applied(ctx context.Context) (map[int]bool, error) {
+	cursor, err := l.coll.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var docs []appliedMigration
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	done := map[int]bool{}
+	for _, d := range docs {
+		done[d.Version] = true
+	}
+	return done, nil
+}
+
+func (l mongoMigrationLog) record(ctx context.Context, m Migration) error {
+	doc := appliedMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}
+	_, err := l.coll.ReplaceOne(ctx, bson.M{"_id": m.Version}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Pending lists the migrations db has not applied yet.
+func Pending(ctx context.Context, db *mongo.Database, colls config.Collections, migrations []Migration) ([]Migration, error) {
+	return pending(ctx, mongoMigrationLog{db.Collection(colls.Migrations)}, migrations)
+}
+
+func pending(ctx context.Context, log migrationLog, migrations []Migration) ([]Migration, error) {
+	done, err := log.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var todo []Migration
+	last := 0
+	for _, m := range migrations {
+		if m.Version <= last {
+			return nil, fmt.Errorf("migration %d %q is out of order", m.Version, m.Name)
+		}
+		last = m.Version
+		if !done[m.Version] {
+			todo = append(todo, m)
+		}
+	}
+	return todo, nil
+}
+
+// Migrate applies the pending migrations in order and returns the ones it
+// ran. It stops at the first failure; later migrations are left pending.
+func Migrate(ctx context.Context, db *mongo.Database, colls config.Collections, migrations []Migration) ([]Migration, error) {
+	return migrate(ctx, db, colls, mongoMigrationLog{db.Collection(colls.Migrations)}, migrations)
+}
+
+func migrate(ctx context.Context, db *mongo.Database, colls config.Collections, log migrationLog, migrations []Migration) ([]Migration, error) {
+	todo, err := pending(ctx, log, migrations)
+	if err != nil {
+		return nil, err
+	}
+	var ran []Migration
+	for _, m := range todo {
+		if err := m.Up(ctx, db, colls); err != nil {
+			return ran, fmt.Errorf("migration %d %q: %w", m.Version, m.Name, err)
+		}
+		if err := log.record(ctx, m); err != nil {
+			return ran, err
+		}
+		ran = append(ran, m)
+	}
+	return ran, nil
+}
+
+// legacyFields are the keys the driver derived from Go field names before the
+// models had bson tags, mapped to their tagged names, per collection.
+func legacyFields(colls config.Collections) map[string]bson.M {
+	return map[string]bson.M{
+		colls.Events: {
+			"estimatedmins": "estimatedMins",
+			"coorganizers":  "coOrganizers",
+			"finalslotid":   "finalSlotId",
+			"orgid":         "orgId",
+			"sharetoken":    "shareToken",
+		},
+		colls.Availability: {
+			"eventid":     "eventId",
+			"userid":      "userId",
+			"slotid":      "slotId",
+			"orgid":       "orgId",
+			"displayname": "displayName",
+		},
+		colls.Guests: {
+			"eventid":     "eventId",
+			"displayname": "displayName",
+			"tokenhash":   "tokenHash",
+			"orgid":       "orgId",
+			"createdat":   "createdAt",
+		},
+	}
+}
+
+// renameFields moves documents written before the bson tags to the tagged
+// names and stamps them with schema version 1.
+func renameFields(ctx context.Context, db *mongo.Database, colls config.Collections) error {
+	for coll, renames := range legacyFields(colls) {
+		c := db.Collection(coll)
+		if _, err := c.UpdateMany(ctx, bson.M{}, bson.M{"$rename": renames}); err != nil {
+			return err
+		}
+		if _, err := c.UpdateMany(ctx, bson.M{"schemaVersion": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"schemaVersion": 1}}); err != nil {
+			return err
+		}
+	}
+	// $rename cannot reach into arrays, rebuild the slots instead
+	slots := mongo.Pipeline{{{Key: "$set", Value: bson.M{"slots": bson.M{"$map": bson.M{
+		"input": "$slots",
+		"as":    "s",
+		"in": bson.M{
+			"_id":       "$$s._id",
+			"startTime": bson.M{"$ifNull": bson.A{"$$s.startTime", "$$s.starttime"}},
+			"endTime":   bson.M{"$ifNull": bson.A{"$$s.endTime", "$$s.endtime"}},
+		},
+	}}}}}}
+	_, err := db.Collection(colls.Events).UpdateMany(ctx, bson.M{"slots.starttime": bson.M{"$exists": true}}, slots)
+	return err
+}
+
+// seedRevisions creates the revision indexes and records the current state of
+// every live event and answer as its first revision, so replays work from the
+// migration on. Entities that already have a revision are left alone.
+func seedRevisions(ctx context.Context, db *mongo.Database, colls config.Collections) error {
+	if err := indexesOf(revisionIndexes)(ctx, db, colls); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	revisions := db.Collection(colls.Revisions)
+	if err := seedFrom(ctx, db.Collection(colls.Events), revisions, "event", now); err != nil {
+		return err
+	}
+	return seedFrom(ctx, db.Collection(colls.Availability), revisions, "availability", now)
+}
+
+// seedFrom copies the live documents of coll into revisions of entity. Share
+// tokens are not kept, revisions outlive their rotation.
+func seedFrom(ctx context.Context, coll, revisions *mongo.Collection, entity string, at time.Time) error {
+	cursor, err := coll.Find(ctx, bson.M{deletedField: nil})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		delete(doc, "shareToken")
+		eventID := doc["_id"]
+		if entity == "availability" {
+			eventID = doc["eventId"]
+		}
+		rev := bson.M{
+			"eventId":       eventID,
+			"entity":        entity,
+			"at":            at,
+			"deleted":       false,
+			entity:          doc,
+			"orgId":         doc["orgId"],
+			"schemaVersion": SchemaVersion,
+		}
+		_, err := revisions.UpdateOne(ctx, bson.M{"entityId": doc["_id"]}, bson.M{"$setOnInsert": rev}, options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// indexesOf is a migration creating the indexes listed by indexes.
+func indexesOf(indexes func(config.Collections) map[string][]mongo.IndexModel) func(context.Context, *mongo.Database, config.Collections) error {
+	return func(ctx context.Context, db *mongo.Database, colls config.Collections) error {
+		for coll, list := range indexes(colls) {
+			if err := EnsureIndexes(ctx, db.Collection(coll), list); err != nil {
+				return fmt.Errorf("%s: %w", coll, err)
+			}
+		}
+		return nil
+	}
+}
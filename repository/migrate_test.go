@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chetanugale/scheduling-system/config"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryLog map[int]bool
+
+func (l memoryLog) applied(context.Context) (map[int]bool, error) { return l, nil }
+func (l memoryLog) record(_ context.Context, m Migration) error {
+	l[m.Version] = true
+	return nil
+}
+
+func TestMigrate(t *testing.T) {
+	var order []int
+	step := func(v int, err error) Migration {
+		return Migration{Version: v, Name: "step", Up: func(context.Context, *mongo.Database, config.Collections) error {
+			order = append(order, v)
+			return err
+		}}
+	}
+	log := memoryLog{1: true}
+	broken := errors.New("boom")
+
+	ran, err := migrate(context.Background(), nil, config.Default().Collections, log, []Migration{step(1, nil), step(2, nil), step(3, broken), step(4, nil)})
+	assert.ErrorIs(t, err, broken)
+	assert.Len(t, ran, 1)
+	assert.Equal(t, []int{2, 3}, order)
+	assert.Equal(t, memoryLog{1: true, 2: true}, log)
+
+	order = nil
+	ran, err = migrate(context.Background(), nil, config.Default().Collections, log, []Migration{step(1, nil), step(2, nil), step(3, nil), step(4, nil)})
+	assert.NoError(t, err)
+	assert.Len(t, ran, 2)
+	assert.Equal(t, []int{3, 4}, order)
+
+	_, err = pending(context.Background(), memoryLog{}, []Migration{step(2, nil), step(1, nil)})
+	assert.Error(t, err)
+}
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	todo, err := pending(context.Background(), memoryLog{}, Migrations)
+	assert.NoError(t, err)
+	assert.Len(t, todo, len(Migrations))
+}
+
+func TestStamp(t *testing.T) {
+	e := models.Event{}
+	stamp(&e)
+	assert.Equal(t, SchemaVersion, e.SchemaVersion)
+
+	tally := models.SlotTally{} // not versioned, left alone
+	stamp(&tally)
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/chetanugale/scheduling-system/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// pageCursor is the decoded form of query.Page.Next: the sort key values and
+// _id of the last document returned.
+type pageCursor struct {
+	Values []bson.RawValue `bson:"v"`
+	ID     bson.RawValue   `bson:"id"`
+}
+
+func encodeCursor(c pageCursor) (string, error) {
+	b, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(token string, keys int) (pageCursor, error) {
+	var c pageCursor
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, query.ErrInvalidCursor
+	}
+	if err := bson.Unmarshal(b, &c); err != nil || len(c.Values) != keys {
+		return c, query.ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// plan is query.FindOptions translated to Mongo keys.
+type plan struct {
+	limit      int64
+	sort       bson.D
+	keys       []string // sort keys, without the _id tie breaker
+	desc       []bool
+	projection bson.M
+}
+
+func planFor[T any](opts query.FindOptions) (plan, error) {
+	fields, sortable := FieldMap[T](), SortFields[T]()
+	p := plan{limit: opts.Limit}
+	if p.limit <= 0 {
+		p.limit = query.DefaultPageSize
+	}
+	p.limit = min(p.limit, query.MaxPageSize)
+	for _, s := range opts.Sort {
+		key, ok := sortable[s.Field]
+		if !ok {
+			return p, fmt.Errorf("%w %q to sort by", query.ErrUnknownField, s.Field)
+		}
+		if key == "_id" {
+			continue // always the final tie breaker
+		}
+		dir := 1
+		if s.Desc {
+			dir = -1
+		}
+		p.sort = append(p.sort, bson.E{Key: key, Value: dir})
+		p.keys = append(p.keys, key)
+		p.desc = append(p.desc, s.Desc)
+	}
+	p.sort = append(p.sort, bson.E{Key: "_id", Value: 1})
+	if len(opts.Fields) > 0 {
+		// sort keys must come back to build the next cursor
+		p.projection = bson.M{"_id": 1}
+		for _, f := range opts.Fields {
+			key, ok := fields[f]
+			if !ok {
+				return p, fmt.Errorf("%w %q", query.ErrUnknownField, f)
+			}
+			p.projection[key] = 1
+		}
+		for _, key := range p.keys {
+			p.projection[key] = 1
+		}
+	}
+	return p, nil
+}
+
+// after builds the keyset condition selecting documents strictly after c in
+// the plan's sort order.
+func (p plan) after(c pageCursor) bson.M {
+	var or bson.A
+	for i := 0; i <= len(p.keys); i++ {
+		cond := bson.M{}
+		for j := 0; j < i; j++ {
+			cond[p.keys[j]] = c.Values[j]
+		}
+		if i == len(p.keys) {
+			cond["_id"] = bson.M{"$gt": c.ID}
+		} else {
+			op := "$gt"
+			if p.desc[i] {
+				op = "$lt"
+			}
+			cond[p.keys[i]] = bson.M{op: c.Values[i]}
+		}
+		or = append(or, cond)
+	}
+	return bson.M{"$or": or}
+}
+
+func (r *MongoRepositoryImpl[T]) FindPage(ctx context.Context, filter query.Filter, opts query.FindOptions) (*query.Page[T], error) {
+	p, err := planFor[T](opts)
+	if err != nil {
+		return nil, err
+	}
+	match, err := toBSON[T](filter)
+	if err != nil {
+		return nil, err
+	}
+	match = live[T](match)
+	total, err := r.collection.CountDocuments(ctx, match)
+	if err != nil {
+		return nil, err
+	}
+
+	find := match
+	if opts.After != "" {
+		c, err := decodeCursor(opts.After, len(p.keys))
+		if err != nil {
+			return nil, err
+		}
+		find = bson.M{"$and": bson.A{match, p.after(c)}}
+	}
+	findOpts := options.Find().SetSort(p.sort).SetLimit(p.limit + 1) // one extra to know if there is a next page
+	if p.projection != nil {
+		findOpts.SetProjection(p.projection)
+	}
+	cursor, err := r.collection.Find(ctx, find, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	page := &query.Page[T]{Items: []T{}, Total: total}
+	var last bson.Raw
+	for cursor.Next(ctx) {
+		if int64(len(page.Items)) == p.limit {
+			next := pageCursor{ID: last.Lookup("_id")}
+			for _, key := range p.keys {
+				next.Values = append(next.Values, last.Lookup(key))
+			}
+			if page.Next, err = encodeCursor(next); err != nil {
+				return nil, err
+			}
+			break
+		}
+		var elem T
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, elem)
+		last = append(bson.Raw(nil), cursor.Current...)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
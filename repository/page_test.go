@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFieldMap(t *testing.T) {
+	fields := FieldMap[models.Event]()
+	assert.Equal(t, "_id", fields["id"])
+	assert.Equal(t, "estimatedMins", fields["estimatedMins"])
+	assert.NotContains(t, FieldMap[models.Guest](), "-")
+}
+
+func TestSortFields(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"id": "_id", "title": "title", "estimatedMins": "estimatedMins", "status": "status", "orgId": "orgId",
+	}, SortFields[models.Event]())
+	assert.Equal(t, "at", SortFields[models.AuditEntry]()["at"])
+	assert.NotContains(t, SortFields[models.Availability](), "displayName")
+}
+
+func TestPlanFor(t *testing.T) {
+	p, err := planFor[models.Event](query.FindOptions{
+		Limit:  1000,
+		Sort:   []query.SortField{{Field: "status"}, {Field: "title", Desc: true}},
+		Fields: []string{"estimatedMins"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(query.MaxPageSize), p.limit)
+	assert.Equal(t, bson.D{{Key: "status", Value: 1}, {Key: "title", Value: -1}, {Key: "_id", Value: 1}}, p.sort)
+	assert.Equal(t, bson.M{"_id": 1, "estimatedMins": 1, "status": 1, "title": 1}, p.projection)
+
+	p, err = planFor[models.Event](query.FindOptions{After: "abc"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(query.DefaultPageSize), p.limit, "a page without a limit")
+
+	_, err = planFor[models.Event](query.FindOptions{Sort: []query.SortField{{Field: "nope"}}})
+	assert.ErrorIs(t, err, query.ErrUnknownField)
+	for _, f := range []string{"slots", "organizers", "finalSlotId", "shareToken", "recurrence"} {
+		_, err = planFor[models.Event](query.FindOptions{Sort: []query.SortField{{Field: f}}})
+		assert.ErrorIs(t, err, query.ErrUnknownField, f)
+	}
+	_, err = planFor[models.Event](query.FindOptions{Fields: []string{"organizers.0"}})
+	assert.ErrorIs(t, err, query.ErrUnknownField)
+}
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	raw, err := bson.Marshal(bson.M{"_id": id, "title": "retro"})
+	assert.NoError(t, err)
+	doc := bson.Raw(raw)
+
+	token, err := encodeCursor(pageCursor{ID: doc.Lookup("_id"), Values: []bson.RawValue{doc.Lookup("title")}})
+	assert.NoError(t, err)
+
+	c, err := decodeCursor(token, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, id, c.ID.ObjectID())
+	assert.Equal(t, "retro", c.Values[0].StringValue())
+
+	_, err = decodeCursor(token, 2) // sort changed between pages
+	assert.ErrorIs(t, err, query.ErrInvalidCursor)
+	_, err = decodeCursor("not a cursor", 1)
+	assert.ErrorIs(t, err, query.ErrInvalidCursor)
+}
+
+func TestPlanAfter(t *testing.T) {
+	p, _ := planFor[models.Event](query.FindOptions{Sort: []query.SortField{{Field: "title", Desc: true}}})
+	raw, _ := bson.Marshal(bson.M{"_id": primitive.NewObjectID(), "title": "retro"})
+	title, idValue := bson.Raw(raw).Lookup("title"), bson.Raw(raw).Lookup("_id")
+
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"title": bson.M{"$lt": title}},
+		bson.M{"title": title, "_id": bson.M{"$gt": idValue}},
+	}}, p.after(pageCursor{Values: []bson.RawValue{title}, ID: idValue}))
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// matrixPipeline groups an event's availability twice in one pass: the slots
+// of every user ("rows", sorted by user id, with the display name) and the
+// number of distinct users per slot ("totals").
+func matrixPipeline(eventID primitive.ObjectID) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"eventId": eventID}}},
+		{{Key: "$facet", Value: bson.M{
+			"rows": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":         "$userId",
+					"displayName": bson.M{"$max": "$displayName"},
+					"slots":       bson.M{"$addToSet": "$slotId"},
+				}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"totals": bson.A{
+				bson.M{"$group": bson.M{"_id": "$slotId", "users": bson.M{"$addToSet": "$userId"}}},
+				bson.M{"$project": bson.M{"count": bson.M{"$size": "$users"}}},
+			},
+		}}},
+	}
+}
+
+// tallyPipeline counts the distinct users per slot of an event ("slots",
+// most popular first) and lists every user who answered ("users").
+func tallyPipeline(eventID primitive.ObjectID) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"eventId": eventID}}},
+		{{Key: "$facet", Value: bson.M{
+			"slots": bson.A{
+				bson.M{"$group": bson.M{"_id": "$slotId", "users": bson.M{"$addToSet": "$userId"}}},
+				bson.M{"$addFields": bson.M{"count": bson.M{"$size": "$users"}}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			},
+			"users": bson.A{
+				bson.M{"$group": bson.M{"_id": nil, "ids": bson.M{"$addToSet": "$userId"}}},
+			},
+		}}},
+	}
+}
+
+// latestRevisionsPipeline keeps, for an event and each of its answers, the
+// last revision recorded at or before at.
+func latestRevisionsPipeline(eventID primitive.ObjectID, at time.Time) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"eventId": eventID, "at": bson.M{"$lte": at}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$entityId", "last": bson.M{"$last": "$$ROOT"}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$last"}}},
+	}
+}
+
+// tallyFacets is the decoded result of tallyPipeline.
+type tallyFacets struct {
+	Slots []models.SlotTally `bson:"slots"`
+	Users []struct {
+		IDs []string `bson:"ids"`
+	} `bson:"users"`
+}
+
+type scopeCtxKey struct{}
+
+// withScope makes the aggregations run under ctx only see documents matching
+// match. The tenant aggregates scope them to the caller's organization with it.
+func withScope(ctx context.Context, match bson.M) context.Context {
+	return context.WithValue(ctx, scopeCtxKey{}, match)
+}
+
+// aggregate runs pipeline on coll, a collection of T, and decodes every result
+// into results, which must be a pointer to a slice. Soft-deleted documents and
+// documents outside the scope of ctx are filtered out by leading $match stages.
+func aggregate[T any](ctx context.Context, coll *mongo.Collection, pipeline mongo.Pipeline, results any) error {
+	if match, ok := ctx.Value(scopeCtxKey{}).(bson.M); ok {
+		pipeline = append(mongo.Pipeline{{{Key: "$match", Value: match}}}, pipeline...)
+	}
+	if softDeletes[T]() {
+		pipeline = append(mongo.Pipeline{{{Key: "$match", Value: bson.M{deletedField: nil}}}}, pipeline...)
+	}
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, results)
+}
+
+// AvailabilityAggregates are the aggregations over the answers of events, run
+// inside Mongo.
+type AvailabilityAggregates interface {
+	Matrix(ctx context.Context, eventID primitive.ObjectID) (*models.MatrixCounts, error)
+	TallySlots(ctx context.Context, eventID primitive.ObjectID) (*models.SlotTallies, error)
+}
+
+// MongoAvailabilityAggregates runs the aggregations on the availability collection.
+type MongoAvailabilityAggregates struct {
+	collection *mongo.Collection
+}
+
+func NewAvailabilityAggregates(coll *mongo.Collection) AvailabilityAggregates {
+	return &MongoAvailabilityAggregates{collection: coll}
+}
+
+// Matrix groups the answers of an event per user and per slot, see
+// matrixPipeline.
+func (r *MongoAvailabilityAggregates) Matrix(ctx context.Context, eventID primitive.ObjectID) (*models.MatrixCounts, error) {
+	var facets []models.MatrixCounts
+	if err := aggregate[models.Availability](ctx, r.collection, matrixPipeline(eventID), &facets); err != nil {
+		return nil, err
+	}
+	if len(facets) == 0 {
+		return &models.MatrixCounts{}, nil
+	}
+	return &facets[0], nil
+}
+
+// TallySlots counts the distinct users available per slot of an event, most
+// popular first.
+func (r *MongoAvailabilityAggregates) TallySlots(ctx context.Context, eventID primitive.ObjectID) (*models.SlotTallies, error) {
+	var facets []tallyFacets
+	if err := aggregate[models.Availability](ctx, r.collection, tallyPipeline(eventID), &facets); err != nil {
+		return nil, err
+	}
+	tallies := &models.SlotTallies{Slots: []models.SlotTally{}, Users: []string{}}
+	if len(facets) > 0 {
+		tallies.Slots = append(tallies.Slots, facets[0].Slots...)
+		if len(facets[0].Users) > 0 {
+			tallies.Users = facets[0].Users[0].IDs
+		}
+	}
+	return tallies, nil
+}
+
+// RevisionHistory reads back the revision log, which is otherwise only
+// inserted into.
+type RevisionHistory interface {
+	// LatestRevisions returns the last revision at or before at of an event
+	// and of each of its answers.
+	LatestRevisions(ctx context.Context, eventID primitive.ObjectID, at time.Time) ([]models.Revision, error)
+}
+
+// MongoRevisionHistory runs the aggregations on the revisions collection.
+type MongoRevisionHistory struct {
+	collection *mongo.Collection
+}
+
+func NewRevisionHistory(coll *mongo.Collection) RevisionHistory {
+	return &MongoRevisionHistory{collection: coll}
+}
+
+func (r *MongoRevisionHistory) LatestRevisions(ctx context.Context, eventID primitive.ObjectID, at time.Time) ([]models.Revision, error) {
+	var revs []models.Revision
+	if err := aggregate[models.Revision](ctx, r.collection, latestRevisionsPipeline(eventID, at), &revs); err != nil {
+		return nil, err
+	}
+	return revs, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// deletedField holds the deletion time of soft-deleted documents.
+const deletedField = "deletedAt"
+
+// SoftDeletable is implemented by models whose DeleteByID only marks the
+// document as deleted. Marked documents are invisible to every other call
+// until RestoreByID clears the mark or PurgeDeleted removes them for good.
+type SoftDeletable interface {
+	SetDeletedAt(t *time.Time)
+}
+
+func softDeletes[T any]() bool {
+	_, ok := any(new(T)).(SoftDeletable)
+	return ok
+}
+
+// live restricts match to the documents that are not soft-deleted.
+func live[T any](match bson.M) bson.M {
+	if !softDeletes[T]() {
+		return match
+	}
+	return bson.M{"$and": bson.A{match, bson.M{deletedField: nil}}} // null also matches a missing field
+}
+
+// GetDeletedByID loads a soft-deleted document. It fails with
+// mongo.ErrNoDocuments when id does not exist or is not deleted.
+func (r *MongoRepositoryImpl[T]) GetDeletedByID(ctx context.Context, id string) (*T, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var result T
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID, deletedField: bson.M{"$ne": nil}}).Decode(&result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RestoreByID clears the deletion mark of id. It fails with
+// mongo.ErrNoDocuments when id does not exist or is not deleted.
+func (r *MongoRepositoryImpl[T]) RestoreByID(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	res, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objID, deletedField: bson.M{"$ne": nil}},
+		bson.M{"$unset": bson.M{deletedField: ""}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteMany deletes the live documents matching filter the way DeleteByID
+// does, and returns how many it deleted.
+func (r *MongoRepositoryImpl[T]) DeleteMany(ctx context.Context, filter query.Filter) (int64, error) {
+	match, err := toBSON[T](filter)
+	if err != nil {
+		return 0, err
+	}
+	if !softDeletes[T]() {
+		res, err := r.collection.DeleteMany(ctx, match)
+		if err != nil {
+			return 0, err
+		}
+		return res.DeletedCount, nil
+	}
+	res, err := r.collection.UpdateMany(ctx, live[T](match), bson.M{"$set": bson.M{deletedField: time.Now()}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// RestoreMany clears the deletion mark of the documents matching filter that
+// were deleted at or after since, and returns how many it restored. Documents
+// deleted earlier, on their own, stay deleted.
+func (r *MongoRepositoryImpl[T]) RestoreMany(ctx context.Context, filter query.Filter, since time.Time) (int64, error) {
+	if !softDeletes[T]() {
+		return 0, nil
+	}
+	match, err := toBSON[T](filter)
+	if err != nil {
+		return 0, err
+	}
+	res, err := r.collection.UpdateMany(ctx,
+		bson.M{"$and": bson.A{match, bson.M{deletedField: bson.M{"$gte": since}}}},
+		bson.M{"$unset": bson.M{deletedField: ""}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// PurgeDeleted removes the documents matching filter that were soft-deleted
+// before cutoff and returns how many it removed.
+func (r *MongoRepositoryImpl[T]) PurgeDeleted(ctx context.Context, filter query.Filter, cutoff time.Time) (int64, error) {
+	if !softDeletes[T]() {
+		return 0, nil
+	}
+	match, err := toBSON[T](filter)
+	if err != nil {
+		return 0, err
+	}
+	res, err := r.collection.DeleteMany(ctx, bson.M{"$and": bson.A{match, bson.M{deletedField: bson.M{"$lt": cutoff}}}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
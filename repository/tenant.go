@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/chetanugale/scheduling-system/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// tenantField is the field holding the owning organization on every document.
+const tenantField = "orgId"
+
+// Tenanted is implemented by models that belong to an organization.
+type Tenanted interface {
+	GetOrgID() string
+	SetOrgID(org string)
+}
+
+// TenantRepository scopes every call on the wrapped repository to the
+// organization carried by the request context. Documents of other
+// organizations behave as if they do not exist.
+type TenantRepository[T any, PT interface {
+	*T
+	Tenanted
+}] struct {
+	inner MongoRepository[T]
+}
+
+func NewTenantRepository[T any, PT interface {
+	*T
+	Tenanted
+}](inner MongoRepository[T]) MongoRepository[T] {
+	return &TenantRepository[T, PT]{inner: inner}
+}
+
+// scope restricts filter to the given organization.
+func scope(filter query.Filter, org string) query.Filter {
+	return query.And(filter, query.Eq(tenantField, org))
+}
+
+// one loads id only if it belongs to the caller's organization.
+func (r *TenantRepository[T, PT]) one(ctx context.Context, id string) (*T, string, error) {
+	org, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	doc, err := r.inner.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if PT(doc).GetOrgID() != org {
+		return nil, "", mongo.ErrNoDocuments
+	}
+	return doc, org, nil
+}
+
+func (r *TenantRepository[T, PT]) Insert(ctx context.Context, doc T) (*T, error) {
+	org, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	PT(&doc).SetOrgID(org)
+	return r.inner.Insert(ctx, doc)
+}
+
+func (r *TenantRepository[T, PT]) GetByID(ctx context.Context, id string) (*T, error) {
+	doc, _, err := r.one(ctx, id)
+	return doc, err
+}
+
+func (r *TenantRepository[T, PT]) UpdateByID(ctx context.Context, id string, update T) error {
+	_, org, err := r.one(ctx, id)
+	if err != nil {
+		return err
+	}
+	PT(&update).SetOrgID(org) // documents can never be moved to another organization
+	return r.inner.UpdateByID(ctx, id, update)
+}
+
+func (r *TenantRepository[T, PT]) DeleteByID(ctx context.Context, id string) error {
+	if _, _, err := r.one(ctx, id); err != nil {
+		return err
+	}
+	return r.inner.DeleteByID(ctx, id)
+}
+
+func (r *TenantRepository[T, PT]) FindAll(ctx context.Context, filter query.Filter) ([]T, error) {
+	org, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.inner.FindAll(ctx, scope(filter, org))
+}
+
+func (r *TenantRepository[T, PT]) Stream(ctx context.Context, filter query.Filter) iter.Seq2[T, error] {
+	org, err := tenant.FromContext(ctx)
+	if err != nil {
+		return func(yield func(T, error) bool) {
+			var zero T
+			yield(zero, err)
+		}
+	}
+	return r.inner.Stream(ctx, scope(filter, org))
+}
+
+func (r *TenantRepository[T, PT]) FindPage(ctx context.Context, filter query.Filter, opts query.FindOptions) (*query.Page[T], error) {
+	org, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.inner.FindPage(ctx, scope(filter, org), opts)
+}
+
+func (r *TenantRepository[T, PT]) CountDocuments(ctx context.Context, filter query.Filter) (int64, error) {
+	org, err := tenant.FromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return r.inner.CountDocuments(ctx, scope(filter, org))
+}
+
+// GetDeletedByID returns the deleted document only if it belongs to the
+// caller's organization.
+func (r *TenantRepository[T, PT]) GetDeletedByID(ctx context.Context, id string) (*T, error) {
+	org, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := r.inner.GetDeletedByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if PT(doc).GetOrgID() != org {
+		return nil, mongo.ErrNoDocuments
+	}
+	return doc, nil
+}
+
+func (r *TenantRepository[T, PT]) RestoreByID(ctx context.Context, id string) error {
+	if _, err := r.GetDeletedByID(ctx, id); err != nil {
+		return err
+	}
+	return r.inner.RestoreByID(ctx, id)
+}
+
+func (r *TenantRepository[T, PT]) DeleteMany(ctx context.Context, filter query.Filter) (int64, error) {
+	org, err := tenant.FromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return r.inner.DeleteMany(ctx, scope(filter, org))
+}
+
+func (r *TenantRepository[T, PT]) RestoreMany(ctx context.Context, filter query.Filter, since time.Time) (int64, error) {
+	org, err := tenant.FromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return r.inner.RestoreMany(ctx, scope(filter, org), since)
+}
+
+func (r *TenantRepository[T, PT]) PurgeDeleted(ctx context.Context, filter query.Filter, cutoff time.Time) (int64, error) {
+	org, err := tenant.FromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return r.inner.PurgeDeleted(ctx, scope(filter, org), cutoff)
+}
+
+// scoped restricts the aggregations run under ctx over documents of T to the
+// caller's organization, see withScope.
+func scoped[T any](ctx context.Context) (context.Context, error) {
+	org, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return withScope(ctx, bson.M{FieldMap[T]()[tenantField]: org}), nil
+}
+
+// TenantAvailabilityAggregates scopes the aggregations to the organization
+// carried by the request context, like TenantRepository.
+type TenantAvailabilityAggregates struct {
+	inner AvailabilityAggregates
+}
+
+func NewTenantAvailabilityAggregates(inner AvailabilityAggregates) AvailabilityAggregates {
+	return &TenantAvailabilityAggregates{inner: inner}
+}
+
+func (r *TenantAvailabilityAggregates) Matrix(ctx context.Context, eventID primitive.ObjectID) (*models.MatrixCounts, error) {
+	ctx, err := scoped[models.Availability](ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.inner.Matrix(ctx, eventID)
+}
+
+func (r *TenantAvailabilityAggregates) TallySlots(ctx context.Context, eventID primitive.ObjectID) (*models.SlotTallies, error) {
+	ctx, err := scoped[models.Availability](ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.inner.TallySlots(ctx, eventID)
+}
+
+// TenantRevisionHistory scopes the revisions read back to the organization
+// carried by the request context.
+type TenantRevisionHistory struct {
+	inner RevisionHistory
+}
+
+func NewTenantRevisionHistory(inner RevisionHistory) RevisionHistory {
+	return &TenantRevisionHistory{inner: inner}
+}
+
+func (r *TenantRevisionHistory) LatestRevisions(ctx context.Context, eventID primitive.ObjectID, at time.Time) ([]models.Revision, error) {
+	ctx, err := scoped[models.Revision](ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.inner.LatestRevisions(ctx, eventID, at)
+}
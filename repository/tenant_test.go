@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/mocker"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/chetanugale/scheduling-system/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func ctxForOrg(org string) context.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	tenant.Set(c, org)
+	return c
+}
+
+func TestTenantRepositoryScopesQueries(t *testing.T) {
+	inner := new(mocker.MockRepo[models.Event])
+	repo := NewTenantRepository[models.Event](inner)
+	ctx := ctxForOrg("eng")
+
+	inner.On("Insert", mock.Anything, models.Event{Title: "retro", OrgID: "eng"}).Return(&models.Event{}, nil)
+	_, err := repo.Insert(ctx, models.Event{Title: "retro", OrgID: "sales"})
+	assert.NoError(t, err)
+
+	filter := query.Eq("title", "retro")
+	scoped := query.And(filter, query.Eq("orgId", "eng"))
+	inner.On("FindAll", mock.Anything, scoped).Return([]models.Event{}, nil)
+	_, err = repo.FindAll(ctx, filter)
+	assert.NoError(t, err)
+
+	inner.On("FindPage", mock.Anything, query.Eq("orgId", "eng"), query.FindOptions{Limit: 5}).Return(&query.Page[models.Event]{}, nil)
+	_, err = repo.FindPage(ctx, query.Filter{}, query.FindOptions{Limit: 5})
+	assert.NoError(t, err)
+
+	inner.On("Stream", mock.Anything, scoped).Return([]models.Event{{Title: "retro"}}, nil)
+	for e, err := range repo.Stream(ctx, filter) {
+		assert.NoError(t, err)
+		assert.Equal(t, "retro", e.Title)
+	}
+
+	inner.AssertExpectations(t)
+}
+
+func TestTenantRepositoryHidesOtherOrgs(t *testing.T) {
+	inner := new(mocker.MockRepo[models.Event])
+	repo := NewTenantRepository[models.Event](inner)
+	id := primitive.NewObjectID()
+
+	inner.On("GetByID", mock.Anything, id.Hex()).Return(&models.Event{ID: id, OrgID: "sales"}, nil)
+
+	_, err := repo.GetByID(ctxForOrg("eng"), id.Hex())
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	assert.ErrorIs(t, repo.DeleteByID(ctxForOrg("eng"), id.Hex()), mongo.ErrNoDocuments)
+	inner.AssertNotCalled(t, "DeleteByID", mock.Anything, mock.Anything)
+
+	_, err = repo.FindAll(context.Background(), query.Filter{})
+	assert.ErrorIs(t, err, tenant.ErrNoOrg)
+	for _, err := range repo.Stream(context.Background(), query.Filter{}) {
+		assert.ErrorIs(t, err, tenant.ErrNoOrg)
+	}
+}
+
+func TestTenantAggregations(t *testing.T) {
+	inner := new(mocker.MockAggregates)
+	repo := NewTenantAvailabilityAggregates(inner)
+	eventID := primitive.NewObjectID()
+
+	scopedToEng := mock.MatchedBy(func(ctx context.Context) bool {
+		match, _ := ctx.Value(scopeCtxKey{}).(bson.M)
+		return match["orgId"] == "eng"
+	})
+	inner.On("Matrix", scopedToEng, eventID).Return(&models.MatrixCounts{}, nil)
+	inner.On("TallySlots", scopedToEng, eventID).Return(&models.SlotTallies{}, nil)
+
+	_, err := repo.Matrix(ctxForOrg("eng"), eventID)
+	assert.NoError(t, err)
+	_, err = repo.TallySlots(ctxForOrg("eng"), eventID)
+	assert.NoError(t, err)
+	_, err = repo.TallySlots(context.Background(), eventID)
+	assert.ErrorIs(t, err, tenant.ErrNoOrg)
+	inner.AssertExpectations(t)
+
+	history := new(mocker.MockRevisionHistory)
+	at := time.Now()
+	history.On("LatestRevisions", scopedToEng, eventID, at).Return([]models.Revision{}, nil)
+	_, err = NewTenantRevisionHistory(history).LatestRevisions(ctxForOrg("eng"), eventID, at)
+	assert.NoError(t, err)
+	history.AssertExpectations(t)
+}
+
+func TestTenantRepositoryRestore(t *testing.T) {
+	inner := new(mocker.MockRepo[models.Event])
+	repo := NewTenantRepository[models.Event](inner)
+	ours, theirs := primitive.NewObjectID(), primitive.NewObjectID()
+
+	inner.On("GetDeletedByID", mock.Anything, ours.Hex()).Return(&models.Event{ID: ours, OrgID: "eng"}, nil)
+	inner.On("GetDeletedByID", mock.Anything, theirs.Hex()).Return(&models.Event{ID: theirs, OrgID: "sales"}, nil)
+	inner.On("RestoreByID", mock.Anything, ours.Hex()).Return(nil)
+
+	assert.NoError(t, repo.RestoreByID(ctxForOrg("eng"), ours.Hex()))
+	assert.ErrorIs(t, repo.RestoreByID(ctxForOrg("eng"), theirs.Hex()), mongo.ErrNoDocuments)
+	inner.AssertNotCalled(t, "RestoreByID", mock.Anything, theirs.Hex())
+
+	cutoff := time.Now()
+	inner.On("PurgeDeleted", mock.Anything, query.And(query.Filter{}, query.Eq("orgId", "eng")), cutoff).Return(int64(2), nil)
+	n, err := repo.PurgeDeleted(ctxForOrg("eng"), query.Filter{}, cutoff)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	byEvent := query.Eq("eventId", ours)
+	inner.On("DeleteMany", mock.Anything, query.And(byEvent, query.Eq("orgId", "eng"))).Return(int64(3), nil)
+	inner.On("RestoreMany", mock.Anything, query.And(byEvent, query.Eq("orgId", "eng")), cutoff).Return(int64(3), nil)
+	_, err = repo.DeleteMany(ctxForOrg("eng"), byEvent)
+	assert.NoError(t, err)
+	_, err = repo.RestoreMany(ctxForOrg("eng"), byEvent, cutoff)
+	assert.NoError(t, err)
+	inner.AssertExpectations(t)
+}
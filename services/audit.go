@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/logging"
+	"github.com/chetanugale/scheduling-system/metrics"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/chetanugale/scheduling-system/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditLog is the append-only record of changes to events and their answers.
+type AuditLog interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+	History(ctx context.Context, eventID primitive.ObjectID, opts query.FindOptions) (*query.Page[models.AuditEntry], error)
+}
+
+// MongoAuditLog keeps the entries in a collection it only ever inserts into.
+type MongoAuditLog struct {
+	Repo repository.MongoRepository[models.AuditEntry]
+}
+
+func (l *MongoAuditLog) Record(ctx context.Context, entry models.AuditEntry) error {
+	_, err := l.Repo.Insert(ctx, entry)
+	return err
+}
+
+// History lists the entries of an event and its answers, oldest first unless
+// opts sorts otherwise.
+func (l *MongoAuditLog) History(ctx context.Context, eventID primitive.ObjectID, opts query.FindOptions) (*query.Page[models.AuditEntry], error) {
+	return l.Repo.FindPage(ctx, query.Eq("eventId", eventID), opts)
+}
+
+const (
+	auditEvent        = "event"
+	auditAvailability = "availability"
+)
+
+// unaudited fields never show up in changes, the entry itself carries them.
+var unaudited = []string{"id", "orgId"}
+
+// redactedFields show up as changed without their values: a secret in an
+// append-only log would outlive its rotation.
+var redactedFields = []string{"shareToken"}
+
+// diff lists the top level JSON fields that differ between before and after.
+// Pass nil for the side that does not exist.
+func diff(before, after any) ([]models.FieldChange, error) {
+	old, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range old {
+		names = append(names, name)
+	}
+	for name := range cur {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var changes []models.FieldChange
+	for _, name := range names {
+		if slices.Contains(unaudited, name) || reflect.DeepEqual(old[name], cur[name]) {
+			continue
+		}
+		change := models.FieldChange{Field: name, Before: old[name], After: cur[name]}
+		if slices.Contains(redactedFields, name) {
+			change.Before, change.After = redact(change.Before), redact(change.After)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func fields(doc any) (map[string]any, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	return m, json.Unmarshal(b, &m)
+}
+
+func redact(v any) any {
+	if v == nil || v == "" {
+		return v
+	}
+	return "<redacted>"
+}
+
+// audit appends an entry for a change that has already been made. It cannot
+// be undone, so a failure to record it is logged and counted in
+// scheduling_history_entries_lost_total rather than returned.
+func audit(ctx context.Context, log AuditLog, entity, action string, eventID, entityID primitive.ObjectID, before, after any) {
+	if log == nil {
+		return
+	}
+	entry := models.AuditEntry{
+		ID:        primitive.NewObjectID(),
+		EventID:   eventID,
+		Entity:    entity,
+		EntityID:  entityID,
+		Action:    action,
+		At:        time.Now().UTC(),
+		RequestID: logging.RequestID(ctx),
+	}
+	if user, ok := auth.FromContext(ctx); ok {
+		entry.Actor = user.UserID
+	}
+	changes, err := diff(before, after)
+	if err == nil {
+		entry.Changes = changes
+		err = log.Record(ctx, entry)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "audit entry lost", "entity", entity, "entityId", entityID.Hex(), "action", action, "error", err)
+		metrics.HistoryLost("audit")
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/logging"
+	"github.com/chetanugale/scheduling-system/metrics"
+	"github.com/chetanugale/scheduling-system/mocker"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryAudit []models.AuditEntry
+
+func (m *memoryAudit) Record(_ context.Context, e models.AuditEntry) error {
+	*m = append(*m, e)
+	return nil
+}
+
+func (m *memoryAudit) History(_ context.Context, eventID primitive.ObjectID, _ query.FindOptions) (*query.Page[models.AuditEntry], error) {
+	page := &query.Page[models.AuditEntry]{Items: []models.AuditEntry{}}
+	for _, e := range *m {
+		if e.EventID == eventID {
+			page.Items = append(page.Items, e)
+		}
+	}
+	return page, nil
+}
+
+// lostEntries reads scheduling_history_entries_lost_total{log}.
+func lostEntries(t *testing.T, log string) float64 {
+	families, err := metrics.Registry.Gather()
+	assert.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() != "scheduling_history_entries_lost_total" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			if m.GetLabel()[0].GetValue() == log {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestAuditCountsLostEntries(t *testing.T) {
+	entries := new(mocker.MockRepo[models.AuditEntry])
+	entries.On("Insert", mock.Anything, mock.Anything).Return((*models.AuditEntry)(nil), errors.New("write concern timeout"))
+	before := lostEntries(t, "audit")
+
+	audit(ctxAs("alice"), &MongoAuditLog{Repo: entries}, auditEvent, models.AuditCreated, primitive.NewObjectID(), primitive.NewObjectID(), nil, models.Event{Title: "retro"})
+	assert.Equal(t, before+1, lostEntries(t, "audit"))
+}
+
+func TestDiff(t *testing.T) {
+	before := models.Event{ID: primitive.NewObjectID(), Title: "retro", EstimatedMins: 30, OrgID: "acme"}
+	after := before
+	after.Title = "retrospective"
+	after.ShareToken = "secret"
+	after.OrgID = ""
+
+	changes, err := diff(before, after)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.FieldChange{
+		{Field: "shareToken", After: "<redacted>"},
+		{Field: "title", Before: "retro", After: "retrospective"},
+	}, changes)
+
+	changes, err = diff(nil, before)
+	assert.NoError(t, err)
+	for _, c := range changes {
+		assert.Nil(t, c.Before)
+		assert.NotContains(t, []string{"id", "orgId"}, c.Field)
+	}
+}
+
+func TestAuditRecordsDeletes(t *testing.T) {
+	eventID := primitive.NewObjectID()
+	availID := primitive.NewObjectID()
+	event := &models.Event{ID: eventID, Organizers: []string{"owner"}}
+	row := &models.Availability{ID: availID, EventID: eventID, UserID: "alice", SlotID: primitive.NewObjectID()}
+
+	events := new(mocker.MockRepo[models.Event])
+	events.On("GetByID", mock.Anything, eventID.Hex()).Return(event, nil)
+	repo := new(mocker.MockRepo[models.Availability])
+	repo.On("GetByID", mock.Anything, availID.Hex()).Return(row, nil)
+	repo.On("DeleteByID", mock.Anything, availID.Hex()).Return(nil)
+	log := &memoryAudit{}
+	svc := &MongoAvailabilityService{Repo: repo, Events: events, Audit: log}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	auth.SetIdentity(c, auth.Identity{UserID: "owner"})
+	c.Set(logging.RequestIDKey, "req-1")
+	assert.NoError(t, svc.DeleteAvailability(c, availID.Hex()))
+
+	assert.Len(t, *log, 1)
+	entry := (*log)[0]
+	assert.Equal(t, eventID, entry.EventID)
+	assert.Equal(t, availID, entry.EntityID)
+	assert.Equal(t, "availability", entry.Entity)
+	assert.Equal(t, models.AuditDeleted, entry.Action)
+	assert.Equal(t, "owner", entry.Actor)
+	assert.Equal(t, "req-1", entry.RequestID)
+	assert.False(t, entry.At.IsZero())
+	assert.Contains(t, entry.Changes, models.FieldChange{Field: "userId", Before: "alice"})
+
+	history, err := (&MongoEventService{Repo: events, Audit: log}).GetEventHistory(ctxAs("owner"), eventID.Hex(), query.FindOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, history.Items, 1)
+}
+
+func TestGetEventHistoryAccess(t *testing.T) {
+	eventID := primitive.NewObjectID()
+	gone := primitive.NewObjectID()
+	events := new(mocker.MockRepo[models.Event])
+	events.On("GetByID", mock.Anything, eventID.Hex()).Return(&models.Event{ID: eventID, Organizers: []string{"owner"}}, nil)
+	log := &memoryAudit{{EventID: gone, Action: models.AuditDeleted}}
+	svc := &MongoEventService{Repo: events, Audit: log}
+
+	_, err := svc.GetEventHistory(ctxAs("bob"), eventID.Hex(), query.FindOptions{})
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = svc.GetEventHistory(context.Background(), eventID.Hex(), query.FindOptions{})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	// admins can still read the history once the event is gone
+	history, err := svc.GetEventHistory(ctxAs("root", auth.RoleAdmin), gone.Hex(), query.FindOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, history.Items, 1)
+	events.AssertNotCalled(t, "GetByID", mock.Anything, gone.Hex())
+}
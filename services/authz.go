@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/models"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("not allowed to perform this action")
+	ErrInvalidSlot     = errors.New("slot does not belong to event")
+)
+
+// caller returns the authenticated identity carried by ctx.
+func caller(ctx context.Context) (auth.Identity, error) {
+	id, ok := auth.FromContext(ctx)
+	if !ok || id.UserID == "" {
+		return auth.Identity{}, ErrUnauthenticated
+	}
+	return id, nil
+}
+
+// IsOrganizer reports whether the user owns the event. Admins own every event.
+func IsOrganizer(id auth.Identity, e models.Event) bool {
+	return id.HasRole(auth.RoleAdmin) || slices.Contains(e.Organizers, id.UserID)
+}
+
+// CanManageEvent reports whether the user may update or finalize the event and
+// see other users' responses.
+func CanManageEvent(id auth.Identity, e models.Event) bool {
+	return IsOrganizer(id, e) || slices.Contains(e.CoOrganizers, id.UserID)
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/chetanugale/scheduling-system/repository"
+	"github.com/chetanugale/scheduling-system/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxDisplayName = 100
+
+var (
+	ErrPollNotFound     = errors.New("poll not found")
+	ErrPollClosed       = errors.New("poll is closed")
+	ErrInvalidEditToken = errors.New("invalid edit token")
+	ErrInvalidName      = errors.New("display name is required and cannot hold control characters")
+)
+
+// GuestResponse is returned to a guest after answering a shared poll. EditToken
+// is only filled in when the guest is first created.
+type GuestResponse struct {
+	ParticipantID string                `json:"participantId"`
+	DisplayName   string                `json:"displayName"`
+	EditToken     string                `json:"editToken,omitempty"`
+	Availability  []models.Availability `json:"availability"`
+}
+
+// GuestService serves public poll links to participants without accounts.
+type GuestService interface {
+	GetSharedEvent(ctx context.Context, shareToken string) (*models.Event, error)
+	Respond(ctx context.Context, shareToken string, name string, slotIDs []string) (*GuestResponse, error)
+	GetResponse(ctx context.Context, shareToken string, editToken string) (*GuestResponse, error)
+	UpdateResponse(ctx context.Context, shareToken string, editToken string, slotIDs []string) (*GuestResponse, error)
+}
+
+type MongoGuestService struct {
+	// Events must not be tenant scoped: share tokens are resolved before the
+	// organization is known.
+	Events repository.MongoRepository[models.Event]
+	Guests repository.MongoRepository[models.Guest]
+	Avail  AvailabilityService
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *MongoGuestService) GetSharedEvent(ctx context.Context, shareToken string) (*models.Event, error) {
+	if shareToken == "" {
+		return nil, ErrPollNotFound
+	}
+	events, err := s.Events.FindAll(ctx, query.Eq("shareToken", shareToken))
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrPollNotFound
+	}
+	return &events[0], nil
+}
+
+// openPoll resolves the shared event and scopes ctx to its organization.
+func (s *MongoGuestService) openPoll(ctx context.Context, shareToken string) (context.Context, *models.Event, error) {
+	event, err := s.GetSharedEvent(ctx, shareToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	if event.Status == models.EventStatusFinalized {
+		return nil, nil, ErrPollClosed
+	}
+	return tenant.WithOrg(ctx, event.OrgID), event, nil
+}
+
+func asGuest(ctx context.Context, g models.Guest) context.Context {
+	return auth.WithIdentity(ctx, auth.Identity{UserID: g.UserID(), Name: g.DisplayName, Method: "guest"})
+}
+
+func (s *MongoGuestService) Respond(ctx context.Context, shareToken string, name string, slotIDs []string) (*GuestResponse, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxDisplayName || strings.ContainsFunc(name, unicode.IsControl) {
+		return nil, ErrInvalidName // names end up in calendar exports
+	}
+	ctx, event, err := s.openPoll(ctx, shareToken)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := slotsOf(*event, slotIDs); err != nil {
+		return nil, err
+	}
+	editToken, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	guest, err := s.Guests.Insert(ctx, models.Guest{
+		ID:          primitive.NewObjectID(),
+		EventID:     event.ID,
+		DisplayName: name,
+		TokenHash:   hashToken(editToken),
+		CreatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	answers, err := SetAnswers(asGuest(ctx, *guest), s.Avail, *event, slotIDs)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "guest responded", "eventId", event.ID.Hex(), "guestId", guest.ID.Hex(), "slots", len(answers))
+	return &GuestResponse{ParticipantID: guest.UserID(), DisplayName: guest.DisplayName, EditToken: editToken, Availability: answers}, nil
+}
+
+func (s *MongoGuestService) guest(ctx context.Context, event models.Event, editToken string) (*models.Guest, error) {
+	if editToken == "" {
+		return nil, ErrInvalidEditToken
+	}
+	guests, err := s.Guests.FindAll(ctx, query.And(query.Eq("eventId", event.ID), query.Eq("tokenHash", hashToken(editToken))))
+	if err != nil {
+		return nil, err
+	}
+	if len(guests) == 0 {
+		return nil, ErrInvalidEditToken
+	}
+	return &guests[0], nil
+}
+
+func (s *MongoGuestService) GetResponse(ctx context.Context, shareToken string, editToken string) (*GuestResponse, error) {
+	event, err := s.GetSharedEvent(ctx, shareToken)
+	if err != nil {
+		return nil, err
+	}
+	ctx = tenant.WithOrg(ctx, event.OrgID)
+	g, err := s.guest(ctx, *event, editToken)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := s.Avail.GetAvailabilitiesByEvent(asGuest(ctx, *g), event.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	return &GuestResponse{ParticipantID: g.UserID(), DisplayName: g.DisplayName, Availability: answers}, nil
+}
+
+func (s *MongoGuestService) UpdateResponse(ctx context.Context, shareToken string, editToken string, slotIDs []string) (*GuestResponse, error) {
+	ctx, event, err := s.openPoll(ctx, shareToken)
+	if err != nil {
+		return nil, err
+	}
+	g, err := s.guest(ctx, *event, editToken)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := SetAnswers(asGuest(ctx, *g), s.Avail, *event, slotIDs)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "guest response updated", "eventId", event.ID.Hex(), "guestId", g.ID.Hex(), "slots", len(answers))
+	return &GuestResponse{ParticipantID: g.UserID(), DisplayName: g.DisplayName, Availability: answers}, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/mocker"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/chetanugale/scheduling-system/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func sharedPoll(status string) (*models.Event, *mocker.MockRepo[models.Event]) {
+	event := &models.Event{
+		ID:         primitive.NewObjectID(),
+		Slots:      []models.TimeSlot{{ID: primitive.NewObjectID()}, {ID: primitive.NewObjectID()}},
+		OrgID:      "eng",
+		Status:     status,
+		ShareToken: "share",
+	}
+	events := new(mocker.MockRepo[models.Event])
+	events.On("FindAll", mock.Anything, query.Eq("shareToken", "share")).Return([]models.Event{*event}, nil)
+	events.On("FindAll", mock.Anything, mock.Anything).Return([]models.Event{}, nil)
+	return event, events
+}
+
+// isGuestCtx checks that calls are made as the guest, inside the poll's organization.
+func isGuestCtx(ctx context.Context) bool {
+	id, ok := auth.FromContext(ctx)
+	org, _ := tenant.FromContext(ctx)
+	return ok && id.Method == "guest" && org == "eng"
+}
+
+func TestGuestRespond(t *testing.T) {
+	event, events := sharedPoll(models.EventStatusOpen)
+	guests := new(mocker.MockRepo[models.Guest])
+	avail := new(mocker.MockAvailabilityService)
+	svc := &MongoGuestService{Events: events, Guests: guests, Avail: avail}
+
+	guests.On("Insert", mock.Anything, mock.MatchedBy(func(g models.Guest) bool {
+		return g.DisplayName == "Partner" && g.TokenHash != ""
+	})).Return(&models.Guest{ID: primitive.NewObjectID(), EventID: event.ID, DisplayName: "Partner"}, nil)
+	avail.On("GetAvailabilitiesByEvent", mock.MatchedBy(isGuestCtx), event.ID.Hex()).Return([]models.Availability{}, nil)
+	avail.On("AddAvailability", mock.MatchedBy(isGuestCtx), mock.MatchedBy(func(a models.Availability) bool {
+		return a.SlotID == event.Slots[1].ID && a.DisplayName == "Partner"
+	})).Return(&models.Availability{SlotID: event.Slots[1].ID}, nil)
+
+	resp, err := svc.Respond(context.Background(), "share", "  Partner ", []string{event.Slots[1].ID.Hex()})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.EditToken)
+	assert.Len(t, resp.Availability, 1)
+	avail.AssertExpectations(t)
+
+	_, err = svc.Respond(context.Background(), "share", "Partner", []string{primitive.NewObjectID().Hex()})
+	assert.ErrorIs(t, err, ErrInvalidSlot)
+	_, err = svc.Respond(context.Background(), "share", " ", nil)
+	assert.ErrorIs(t, err, ErrInvalidName)
+	_, err = svc.Respond(context.Background(), "share", "x\r\nATTENDEE:mailto:evil", nil)
+	assert.ErrorIs(t, err, ErrInvalidName)
+	_, err = svc.Respond(context.Background(), "nope", "Partner", nil)
+	assert.ErrorIs(t, err, ErrPollNotFound)
+}
+
+func TestGuestRespondClosedPoll(t *testing.T) {
+	_, events := sharedPoll(models.EventStatusFinalized)
+	svc := &MongoGuestService{Events: events}
+
+	_, err := svc.Respond(context.Background(), "share", "Partner", nil)
+	assert.ErrorIs(t, err, ErrPollClosed)
+}
+
+func TestGuestUpdateResponse(t *testing.T) {
+	event, events := sharedPoll(models.EventStatusOpen)
+	guest := models.Guest{ID: primitive.NewObjectID(), EventID: event.ID, DisplayName: "Partner", TokenHash: hashToken("edit")}
+	guests := new(mocker.MockRepo[models.Guest])
+	guests.On("FindAll", mock.Anything, query.And(query.Eq("eventId", event.ID), query.Eq("tokenHash", hashToken("edit")))).Return([]models.Guest{guest}, nil)
+	guests.On("FindAll", mock.Anything, mock.Anything).Return([]models.Guest{}, nil)
+
+	old := models.Availability{ID: primitive.NewObjectID(), SlotID: event.Slots[0].ID, UserID: guest.UserID()}
+	avail := new(mocker.MockAvailabilityService)
+	avail.On("GetAvailabilitiesByEvent", mock.MatchedBy(isGuestCtx), event.ID.Hex()).Return([]models.Availability{old}, nil)
+	avail.On("DeleteAvailability", mock.MatchedBy(isGuestCtx), old.ID.Hex()).Return(nil)
+	avail.On("AddAvailability", mock.MatchedBy(isGuestCtx), mock.Anything).Return(&models.Availability{SlotID: event.Slots[1].ID}, nil)
+	svc := &MongoGuestService{Events: events, Guests: guests, Avail: avail}
+
+	_, err := svc.UpdateResponse(context.Background(), "share", "wrong", nil)
+	assert.ErrorIs(t, err, ErrInvalidEditToken)
+
+	resp, err := svc.UpdateResponse(context.Background(), "share", "edit", []string{event.Slots[1].ID.Hex()})
+	assert.NoError(t, err)
+	assert.Empty(t, resp.EditToken)
+	assert.Len(t, resp.Availability, 1)
+	avail.AssertExpectations(t)
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/ical"
+	"github.com/chetanugale/scheduling-system/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrEmptyCalendar = errors.New("calendar has no events or free periods")
+
+// MaxImportedSlots bounds the candidate slots of an imported event.
+const MaxImportedSlots = 500
+
+var ErrTooManySlots = fmt.Errorf("calendar has more than %d distinct events and free periods", MaxImportedSlots)
+
+// EventFromCalendar drafts an event whose candidate slots are the calendar's
+// VEVENTs and VFREEBUSY FREE periods. title and estimatedMins fall back to the
+// calendar name and the shortest slot when empty.
+func EventFromCalendar(cal *ical.Calendar, title string, estimatedMins int) (models.Event, error) {
+	var slots []models.TimeSlot
+	seen := map[[2]int64]struct{}{} // start and end, calendars have no finer times than seconds
+	add := func(start, end time.Time) error {
+		key := [2]int64{start.Unix(), end.Unix()}
+		if _, dup := seen[key]; dup || !end.After(start) {
+			return nil
+		}
+		if len(slots) == MaxImportedSlots {
+			return ErrTooManySlots
+		}
+		seen[key] = struct{}{}
+		slots = append(slots, models.TimeSlot{ID: primitive.NewObjectID(), StartTime: start.UTC(), EndTime: end.UTC()})
+		return nil
+	}
+	for _, e := range cal.Events {
+		if err := add(e.Start, e.End); err != nil {
+			return models.Event{}, err
+		}
+	}
+	for _, p := range cal.FreeBusy {
+		if p.Type != ical.FBTypeFree {
+			continue
+		}
+		if err := add(p.Start, p.End); err != nil {
+			return models.Event{}, err
+		}
+	}
+	if len(slots) == 0 {
+		return models.Event{}, ErrEmptyCalendar
+	}
+	slices.SortFunc(slots, func(a, b models.TimeSlot) int { return a.StartTime.Compare(b.StartTime) })
+
+	if title == "" {
+		title = cal.Name
+	}
+	if title == "" && len(cal.Events) > 0 {
+		title = cal.Events[0].Summary
+	}
+	if title == "" {
+		title = "Imported event"
+	}
+	if estimatedMins <= 0 {
+		shortest := slots[0].EndTime.Sub(slots[0].StartTime)
+		for _, s := range slots[1:] {
+			shortest = min(shortest, s.EndTime.Sub(s.StartTime))
+		}
+		estimatedMins = int(shortest.Minutes())
+	}
+	return models.Event{Title: title, EstimatedMins: estimatedMins, Slots: slots, Recurrence: sharedRecurrence(cal), TimeZone: calendarZone(cal)}, nil
+}
+
+// calendarZone is the zone the first VEVENT starts in, when its DTSTART names
+// one with TZID.
+func calendarZone(cal *ical.Calendar) string {
+	if len(cal.Events) == 0 {
+		return ""
+	}
+	switch zone := cal.Events[0].Start.Location().String(); zone {
+	case "UTC", "Local":
+		return ""
+	default:
+		return zone
+	}
+}
+
+// sharedRecurrence keeps the RRULE of the calendar's VEVENTs when they all
+// repeat by the same rule, the slots of a standing meeting. Anything else is
+// imported as single dates.
+func sharedRecurrence(cal *ical.Calendar) *models.Recurrence {
+	if len(cal.Events) == 0 || len(cal.FreeBusy) > 0 {
+		return nil
+	}
+	rec := &models.Recurrence{RRule: cal.Events[0].RRule}
+	for _, e := range cal.Events {
+		if e.RRule == "" || e.RRule != rec.RRule {
+			return nil
+		}
+		for _, t := range e.ExDates {
+			rec.ExDates = append(rec.ExDates, t.UTC())
+		}
+	}
+	if ical.ValidateRRule(rec.RRule) != nil {
+		return nil
+	}
+	return rec
+}
+
+// busyPeriods lists the times a VEVENT blocks around the event's slots, every
+// occurrence of it if it repeats.
+func busyPeriods(e ical.Event, event models.Event) []ical.Period {
+	if e.RRule == "" || len(event.Slots) == 0 {
+		return []ical.Period{{Start: e.Start, End: e.End, Type: ical.FBTypeBusy}}
+	}
+	length := e.End.Sub(e.Start)
+	from, to := event.Slots[0].StartTime, event.Slots[0].EndTime
+	for _, s := range event.Slots {
+		from, to = minTime(from, s.StartTime), maxTime(to, s.EndTime)
+	}
+	starts, err := ical.Expand(e.RRule, e.Start, e.ExDates, from.Add(-length), to, MaxOccurrences)
+	if err != nil {
+		return []ical.Period{{Start: e.Start, End: e.End, Type: ical.FBTypeBusy}}
+	}
+	periods := make([]ical.Period, 0, len(starts))
+	for _, start := range starts {
+		periods = append(periods, ical.Period{Start: start, End: start.Add(length), Type: ical.FBTypeBusy})
+	}
+	return periods
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+func overlaps(aStart, aEnd, bStart, bEnd time.Time) bool {
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+// AvailableSlotIDs returns the event slots a user is free for according to their
+// calendar. When the calendar lists FREE periods a slot must fit inside one;
+// otherwise any slot not overlapping a busy period or opaque VEVENT counts.
+func AvailableSlotIDs(event models.Event, cal *ical.Calendar) []string {
+	var free, busy []ical.Period
+	for _, p := range cal.FreeBusy {
+		if p.Type == ical.FBTypeFree {
+			free = append(free, p)
+		} else {
+			busy = append(busy, p)
+		}
+	}
+	for _, e := range cal.Events {
+		if !e.Transparent {
+			busy = append(busy, busyPeriods(e, event)...)
+		}
+	}
+
+	ids := []string{}
+	for _, slot := range event.Slots {
+		blocked := slices.ContainsFunc(busy, func(p ical.Period) bool {
+			return overlaps(slot.StartTime, slot.EndTime, p.Start, p.End)
+		})
+		if blocked {
+			continue
+		}
+		if len(free) > 0 && !slices.ContainsFunc(free, func(p ical.Period) bool {
+			return !slot.StartTime.Before(p.Start) && !slot.EndTime.After(p.End)
+		}) {
+			continue
+		}
+		ids = append(ids, slot.ID.Hex())
+	}
+	return ids
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/ical"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func at(hour int) time.Time {
+	return time.Date(2025, 5, 6, hour, 0, 0, 0, time.UTC)
+}
+
+func TestEventFromCalendar(t *testing.T) {
+	cal := &ical.Calendar{
+		Events: []ical.Event{{Summary: "Sync", Start: at(14), End: at(15)}, {Start: at(9), End: at(10)}},
+		FreeBusy: []ical.Period{
+			{Start: at(16), End: at(16).Add(30 * time.Minute), Type: ical.FBTypeFree},
+			{Start: at(14), End: at(15), Type: ical.FBTypeFree}, // same as the first VEVENT
+			{Start: at(11), End: at(12), Type: ical.FBTypeBusy},
+		},
+	}
+	e, err := EventFromCalendar(cal, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "Sync", e.Title)
+	assert.Equal(t, 30, e.EstimatedMins)
+	assert.Len(t, e.Slots, 3)
+	assert.True(t, e.Slots[0].StartTime.Equal(at(9)))
+
+	weekly := &ical.Calendar{Events: []ical.Event{
+		{Start: at(9), End: at(10), RRule: "FREQ=WEEKLY", ExDates: []time.Time{at(9).AddDate(0, 0, 7)}},
+		{Start: at(14), End: at(15), RRule: "FREQ=WEEKLY"},
+	}}
+	e, err = EventFromCalendar(weekly, "standup", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Recurrence{RRule: "FREQ=WEEKLY", ExDates: []time.Time{at(9).AddDate(0, 0, 7)}}, e.Recurrence)
+	weekly.Events[1].RRule = "FREQ=DAILY"
+	e, _ = EventFromCalendar(weekly, "standup", 0)
+	assert.Nil(t, e.Recurrence)
+
+	_, err = EventFromCalendar(&ical.Calendar{}, "x", 30)
+	assert.ErrorIs(t, err, ErrEmptyCalendar)
+}
+
+func TestEventFromCalendarSlotLimit(t *testing.T) {
+	cal := &ical.Calendar{}
+	for i := range MaxImportedSlots {
+		start := at(9).Add(time.Duration(i) * time.Hour)
+		cal.Events = append(cal.Events, ical.Event{Start: start, End: start.Add(time.Hour)}, ical.Event{Start: start, End: start.Add(time.Hour)})
+	}
+	e, err := EventFromCalendar(cal, "x", 30)
+	assert.NoError(t, err, "duplicates do not count")
+	assert.Len(t, e.Slots, MaxImportedSlots)
+
+	cal.FreeBusy = []ical.Period{{Start: at(8), End: at(9), Type: ical.FBTypeFree}}
+	_, err = EventFromCalendar(cal, "x", 30)
+	assert.ErrorIs(t, err, ErrTooManySlots)
+}
+
+func TestAvailableSlotIDs(t *testing.T) {
+	event := models.Event{Slots: []models.TimeSlot{
+		{ID: primitive.NewObjectID(), StartTime: at(9), EndTime: at(10)},
+		{ID: primitive.NewObjectID(), StartTime: at(11), EndTime: at(12)},
+		{ID: primitive.NewObjectID(), StartTime: at(14), EndTime: at(15)},
+	}}
+
+	busyOnly := &ical.Calendar{
+		Events:   []ical.Event{{Start: at(9), End: at(10), Transparent: true}},
+		FreeBusy: []ical.Period{{Start: at(11), End: at(13), Type: ical.FBTypeBusy}},
+	}
+	assert.Equal(t, []string{event.Slots[0].ID.Hex(), event.Slots[2].ID.Hex()}, AvailableSlotIDs(event, busyOnly))
+
+	withFree := &ical.Calendar{FreeBusy: []ical.Period{{Start: at(8), End: at(12), Type: ical.FBTypeFree}}}
+	assert.Equal(t, []string{event.Slots[0].ID.Hex(), event.Slots[1].ID.Hex()}, AvailableSlotIDs(event, withFree))
+
+	// a weekly meeting that started a week earlier still blocks the slot
+	recurring := &ical.Calendar{Events: []ical.Event{{Start: at(11).AddDate(0, 0, -7), End: at(12).AddDate(0, 0, -7), RRule: "FREQ=WEEKLY"}}}
+	assert.Equal(t, []string{event.Slots[0].ID.Hex(), event.Slots[2].ID.Hex()}, AvailableSlotIDs(event, recurring))
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/chetanugale/scheduling-system/repository"
+)
+
+// Purger removes soft-deleted events and answers for good once they have been
+// deleted for longer than Retention. It spans every organization, so the
+// repositories must not be tenant scoped.
+type Purger struct {
+	Events    repository.MongoRepository[models.Event]
+	Avail     repository.MongoRepository[models.Availability]
+	Retention time.Duration
+}
+
+// Purge removes everything deleted before the retention period.
+func (p *Purger) Purge(ctx context.Context) error {
+	cutoff := time.Now().Add(-p.Retention)
+	events, err := p.Events.PurgeDeleted(ctx, query.Filter{}, cutoff)
+	if err != nil {
+		return err
+	}
+	answers, err := p.Avail.PurgeDeleted(ctx, query.Filter{}, cutoff)
+	if err != nil {
+		return err
+	}
+	if events > 0 || answers > 0 {
+		slog.InfoContext(ctx, "purged deleted documents", "events", events, "availability", answers, "deletedBefore", cutoff)
+	}
+	return nil
+}
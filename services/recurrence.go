@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/ical"
+	"github.com/chetanugale/scheduling-system/models"
+)
+
+var (
+	ErrInvalidRecurrence = errors.New("invalid recurrence")
+	ErrInvalidWindow     = errors.New("window must end after it starts")
+)
+
+// MaxOccurrences bounds an expansion, whatever the window.
+const MaxOccurrences = 500
+
+// checkRecurrence rejects a rule that cannot be expanded.
+func checkRecurrence(e models.Event) error {
+	if e.Recurrence == nil {
+		return nil
+	}
+	if err := ical.ValidateRRule(e.Recurrence.RRule); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+	return nil
+}
+
+// Occurrences expands the slots of an event into the concrete times that
+// start in [from, to), earliest first. Each occurrence keeps the id of its
+// slot, which is what answers refer to. A finalized event only occurs at its
+// final slot, and an event without recurrence at its slots as they are.
+//
+// Rules repeat in the zone of the event, UTC when it has none, so a weekly
+// 09:00 Berlin meeting stays at 09:00 there across daylight saving changes.
+// Occurrences are returned in that zone.
+func Occurrences(event models.Event, from, to time.Time) ([]models.TimeSlot, error) {
+	if !to.After(from) {
+		return nil, ErrInvalidWindow
+	}
+	loc := time.UTC
+	if event.TimeZone != "" {
+		var err error
+		if loc, err = LoadZone(event.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+	slots := event.Slots
+	if event.FinalSlotID != nil {
+		slots = slices.DeleteFunc(slices.Clone(slots), func(s models.TimeSlot) bool { return s.ID != *event.FinalSlotID })
+	}
+	occurrences := []models.TimeSlot{}
+	for _, slot := range slots {
+		starts := []time.Time{slot.StartTime.In(loc)}
+		if event.Recurrence != nil {
+			var err error
+			starts, err = ical.Expand(event.Recurrence.RRule, slot.StartTime.In(loc), event.Recurrence.ExDates, from, to, MaxOccurrences)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+			}
+		} else if slot.StartTime.Before(from) || !slot.StartTime.Before(to) {
+			continue
+		}
+		length := slot.EndTime.Sub(slot.StartTime)
+		for _, start := range starts {
+			occurrences = append(occurrences, models.TimeSlot{ID: slot.ID, StartTime: start, EndTime: start.Add(length)})
+		}
+	}
+	slices.SortStableFunc(occurrences, func(a, b models.TimeSlot) int { return a.StartTime.Compare(b.StartTime) })
+	if len(occurrences) > MaxOccurrences {
+		occurrences = occurrences[:MaxOccurrences]
+	}
+	return occurrences, nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/mocker"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOccurrences(t *testing.T) {
+	tue := models.TimeSlot{ID: primitive.NewObjectID(), StartTime: at(10), EndTime: at(11)}
+	wed := models.TimeSlot{ID: primitive.NewObjectID(), StartTime: at(10).AddDate(0, 0, 1), EndTime: at(11).AddDate(0, 0, 1)}
+	event := models.Event{
+		Slots:      []models.TimeSlot{wed, tue},
+		Recurrence: &models.Recurrence{RRule: "FREQ=WEEKLY;COUNT=3", ExDates: []time.Time{tue.StartTime.AddDate(0, 0, 7)}},
+	}
+	window := at(0).AddDate(0, 1, 0)
+
+	occ, err := Occurrences(event, at(0), window)
+	assert.NoError(t, err)
+	var starts []time.Time
+	for _, o := range occ {
+		starts = append(starts, o.StartTime)
+		assert.Equal(t, time.Hour, o.EndTime.Sub(o.StartTime))
+	}
+	assert.Equal(t, []time.Time{
+		tue.StartTime, wed.StartTime, wed.StartTime.AddDate(0, 0, 7),
+		tue.StartTime.AddDate(0, 0, 14), wed.StartTime.AddDate(0, 0, 14),
+	}, starts)
+	assert.Equal(t, tue.ID, occ[0].ID) // answers to the pattern apply to every occurrence
+
+	event.FinalSlotID = &wed.ID
+	occ, err = Occurrences(event, at(12), window)
+	assert.NoError(t, err)
+	assert.Len(t, occ, 3)
+
+	single, err := Occurrences(models.Event{Slots: []models.TimeSlot{tue, wed}}, at(0), at(0).AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TimeSlot{tue}, single)
+
+	_, err = Occurrences(event, window, at(0))
+	assert.ErrorIs(t, err, ErrInvalidWindow)
+}
+
+func TestCreateEventRejectsInvalidRecurrence(t *testing.T) {
+	svc := &MongoEventService{Repo: new(mocker.MockRepo[models.Event])}
+	_, err := svc.CreateEvent(ctxAs("alice"), models.Event{Recurrence: &models.Recurrence{RRule: "FREQ=FORTNIGHTLY"}})
+	assert.ErrorIs(t, err, ErrInvalidRecurrence)
+}
+
+func TestOccurrencesInEventZone(t *testing.T) {
+	// 09:00 in Berlin, CET before 29 March 2026 and CEST after
+	slot := models.TimeSlot{ID: primitive.NewObjectID(), StartTime: time.Date(2026, 3, 24, 8, 0, 0, 0, time.UTC), EndTime: time.Date(2026, 3, 24, 9, 0, 0, 0, time.UTC)}
+	event := models.Event{Slots: []models.TimeSlot{slot}, TimeZone: "Europe/Berlin", Recurrence: &models.Recurrence{RRule: "FREQ=WEEKLY;COUNT=2"}}
+
+	occ, err := Occurrences(event, slot.StartTime, slot.StartTime.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	assert.Len(t, occ, 2)
+	for _, o := range occ {
+		assert.Equal(t, 9, o.StartTime.Hour())
+		assert.Equal(t, "Europe/Berlin", o.StartTime.Location().String())
+	}
+	assert.True(t, occ[1].StartTime.Equal(time.Date(2026, 3, 31, 7, 0, 0, 0, time.UTC)))
+
+	event.TimeZone = "Europe/Atlantis"
+	_, err = Occurrences(event, slot.StartTime, slot.StartTime.AddDate(0, 1, 0))
+	assert.ErrorIs(t, err, ErrInvalidTimeZone)
+}
+
+func TestCreateEventRejectsUnknownTimeZone(t *testing.T) {
+	svc := &MongoEventService{Repo: new(mocker.MockRepo[models.Event])}
+	_, err := svc.CreateEvent(ctxAs("alice"), models.Event{TimeZone: "Europe/Atlantis"})
+	assert.ErrorIs(t, err, ErrInvalidTimeZone)
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/metrics"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/repository"
+	"github.com/chetanugale/scheduling-system/tracing"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RevisionLog keeps the full state of events and their answers after every
+// change, so past recommendations can be replayed. The audit log only keeps
+// what changed.
+type RevisionLog interface {
+	Record(ctx context.Context, rev models.Revision) error
+	// AsOf returns the latest revision at or before at of the event and of
+	// each of its answers.
+	AsOf(ctx context.Context, eventID primitive.ObjectID, at time.Time) ([]models.Revision, error)
+}
+
+// MongoRevisionLog keeps the revisions in a collection it only ever inserts
+// into, and reads them back with History.
+type MongoRevisionLog struct {
+	Repo    repository.MongoRepository[models.Revision]
+	History repository.RevisionHistory
+}
+
+func (l *MongoRevisionLog) Record(ctx context.Context, rev models.Revision) error {
+	_, err := l.Repo.Insert(ctx, rev)
+	return err
+}
+
+func (l *MongoRevisionLog) AsOf(ctx context.Context, eventID primitive.ObjectID, at time.Time) ([]models.Revision, error) {
+	return l.History.LatestRevisions(ctx, eventID, at)
+}
+
+// ErrNoRevision is returned when nothing was recorded about an event at the
+// requested time: it did not exist yet, was deleted, or predates revisions.
+var ErrNoRevision = errors.New("no revision of the event at that time")
+
+// revise records the state of an entity right after a change, a
+// models.Event, a models.Availability or nil once deleted. Like audit, a
+// failure is logged and counted rather than returned.
+func revise(ctx context.Context, log RevisionLog, entity string, eventID, entityID primitive.ObjectID, doc any) {
+	if log == nil {
+		return
+	}
+	rev := models.Revision{
+		ID:       primitive.NewObjectID(),
+		EventID:  eventID,
+		Entity:   entity,
+		EntityID: entityID,
+		At:       time.Now().UTC(),
+		Deleted:  doc == nil,
+	}
+	switch d := doc.(type) {
+	case models.Event:
+		d.ShareToken = "" // a secret in an append-only log would outlive its rotation
+		rev.Event = &d
+	case models.Availability:
+		rev.Availability = &d
+	}
+	if err := log.Record(ctx, rev); err != nil {
+		slog.ErrorContext(ctx, "revision lost", "entity", entity, "entityId", entityID.Hex(), "error", err)
+		metrics.HistoryLost("revisions")
+	}
+}
+
+// TallySlotsAsOf returns the event and its tallies as they stood at at, to
+// replay a past recommendation. Access is checked against the event as it is
+// now, with the same rules as TallySlots.
+func (s *MongoAvailabilityService) TallySlotsAsOf(ctx context.Context, eventID string, at time.Time) (_ *models.Event, _ *models.SlotTallies, err error) {
+	ctx, span := tracing.Start(ctx, "AvailabilityService.TallySlotsAsOf")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	current, err := s.Events.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !CanManageEvent(user, *current) {
+		return nil, nil, ErrForbidden
+	}
+	if s.Revisions == nil {
+		return nil, nil, ErrNoRevision
+	}
+	revs, err := s.Revisions.AsOf(ctx, current.ID, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	var event *models.Event
+	var answers []models.Availability
+	for _, rev := range revs {
+		switch {
+		case rev.Deleted:
+		case rev.Event != nil:
+			event = rev.Event
+		case rev.Availability != nil:
+			answers = append(answers, *rev.Availability)
+		}
+	}
+	if event == nil {
+		return nil, nil, ErrNoRevision
+	}
+	return event, Tally(answers), nil
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/chetanugale/scheduling-system/tracing"
+)
+
+var ErrInvalidSearch = errors.New("invalid search")
+
+// searchFilter translates q to a repository filter.
+func searchFilter(q models.EventSearch) (query.Filter, error) {
+	var and []query.Filter
+	if q.Text != "" {
+		and = append(and, query.Text(q.Text))
+	}
+	if !q.From.IsZero() || !q.To.IsZero() {
+		if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+			return query.Filter{}, fmt.Errorf("%w: from must be before to", ErrInvalidSearch)
+		}
+		var from, to any
+		if !q.From.IsZero() {
+			from = q.From
+		}
+		if !q.To.IsZero() {
+			to = q.To
+		}
+		and = append(and, query.Elem("slots", query.Range("startTime", from, to)))
+	}
+	switch q.Status {
+	case "":
+	case models.EventStatusOpen, models.EventStatusFinalized:
+		and = append(and, query.Eq("status", q.Status))
+	default:
+		return query.Filter{}, fmt.Errorf("%w: unknown status %q", ErrInvalidSearch, q.Status)
+	}
+	if q.Organizer != "" {
+		and = append(and, query.Or(query.Eq("organizers", q.Organizer), query.Eq("coOrganizers", q.Organizer)))
+	}
+	return query.And(and...), nil
+}
+
+// SearchEvents lists the events of the caller's organization matching search.
+func (s *MongoEventService) SearchEvents(ctx context.Context, search models.EventSearch, opts query.FindOptions) (_ *query.Page[models.Event], err error) {
+	ctx, span := tracing.Start(ctx, "EventService.SearchEvents")
+	defer func() { tracing.End(span, err) }()
+	filter, err := searchFilter(search)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.FindPage(ctx, filter, opts)
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/mocker"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSearchFilter(t *testing.T) {
+	from := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	filter, err := searchFilter(models.EventSearch{Text: "retro", From: from, To: to, Status: models.EventStatusOpen, Organizer: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, query.And(
+		query.Text("retro"),
+		query.Elem("slots", query.Range("startTime", from, to)),
+		query.Eq("status", models.EventStatusOpen),
+		query.Or(query.Eq("organizers", "alice"), query.Eq("coOrganizers", "alice")),
+	), filter)
+
+	filter, err = searchFilter(models.EventSearch{To: to})
+	assert.NoError(t, err)
+	assert.Equal(t, query.Elem("slots", query.Range("startTime", nil, to)), filter)
+
+	filter, err = searchFilter(models.EventSearch{})
+	assert.NoError(t, err)
+	assert.Equal(t, query.Filter{}, filter)
+
+	_, err = searchFilter(models.EventSearch{From: to, To: from})
+	assert.ErrorIs(t, err, ErrInvalidSearch)
+	_, err = searchFilter(models.EventSearch{Status: "cancelled"})
+	assert.ErrorIs(t, err, ErrInvalidSearch)
+}
+
+func TestSearchEvents(t *testing.T) {
+	repo := new(mocker.MockRepo[models.Event])
+	repo.On("FindPage", mock.Anything, query.Eq("status", models.EventStatusFinalized), query.FindOptions{Limit: 5}).
+		Return(&query.Page[models.Event]{Total: 1}, nil)
+	svc := &MongoEventService{Repo: repo}
+
+	page, err := svc.SearchEvents(ctxAs("alice"), models.EventSearch{Status: models.EventStatusFinalized}, query.FindOptions{Limit: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), page.Total)
+	repo.AssertExpectations(t)
+}
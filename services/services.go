@@ -2,47 +2,324 @@ package services
 
 import (
 	"context"
+	"errors"
+	"iter"
+	"log/slog"
+	"slices"
+	"time"
 
+	"github.com/chetanugale/scheduling-system/auth"
 	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
 	"github.com/chetanugale/scheduling-system/repository"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/chetanugale/scheduling-system/tracing"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type EventService interface {
 	CreateEvent(ctx context.Context, e models.Event) (*models.Event, error)
 	GetEvent(ctx context.Context, id string) (*models.Event, error)
-	UpdateEvent(ctx context.Context, id string, update models.Event) error
+	UpdateEvent(ctx context.Context, id string, update models.Event) (*models.Event, error)
 	DeleteEvent(ctx context.Context, id string) error
-	GetAllEvents(ctx context.Context, title string) ([]models.Event, error)
+	RestoreEvent(ctx context.Context, id string) (*models.Event, error)
+	GetAllEvents(ctx context.Context, title string, opts query.FindOptions) (*query.Page[models.Event], error)
+	SearchEvents(ctx context.Context, search models.EventSearch, opts query.FindOptions) (*query.Page[models.Event], error)
+	FinalizeEvent(ctx context.Context, id string, slotID string) (*models.Event, error)
+	ShareEvent(ctx context.Context, id string) (*models.Event, error)
+	GetEventHistory(ctx context.Context, id string, opts query.FindOptions) (*query.Page[models.AuditEntry], error)
+	GetOccurrences(ctx context.Context, id string, from, to time.Time) ([]models.TimeSlot, error)
 }
 
 type MongoEventService struct {
-	Repo repository.MongoRepository[models.Event]
+	Repo      repository.MongoRepository[models.Event]
+	Answers   repository.MongoRepository[models.Availability] // optional, deleted and restored with their event
+	Audit     AuditLog                                        // optional
+	Revisions RevisionLog                                     // optional
 }
 
-func (s *MongoEventService) CreateEvent(ctx context.Context, e models.Event) (*models.Event, error) {
-	return s.Repo.Insert(ctx, e)
+func (s *MongoEventService) CreateEvent(ctx context.Context, e models.Event) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.CreateEvent")
+	defer func() { tracing.End(span, err) }()
+	id, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(e.Organizers, id.UserID) {
+		e.Organizers = append(e.Organizers, id.UserID) // creator always owns the event
+	}
+	if err := checkRecurrence(e); err != nil {
+		return nil, err
+	}
+	if err := checkTimeZone(e); err != nil {
+		return nil, err
+	}
+	e.Status = models.EventStatusOpen
+	e.FinalSlotID = nil
+	e.DeletedAt = nil
+	if e.ID.IsZero() {
+		e.ID = primitive.NewObjectID() // known before the insert, so the caller gets it back
+	}
+	created, err := s.Repo.Insert(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "event created", "eventId", created.ID.Hex(), "slots", len(created.Slots))
+	audit(ctx, s.Audit, auditEvent, models.AuditCreated, created.ID, created.ID, nil, *created)
+	revise(ctx, s.Revisions, auditEvent, created.ID, created.ID, *created)
+	return created, nil
 }
 
-func (s *MongoEventService) GetEvent(ctx context.Context, id string) (*models.Event, error) {
+func (s *MongoEventService) GetEvent(ctx context.Context, id string) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetEvent")
+	defer func() { tracing.End(span, err) }()
 	return s.Repo.GetByID(ctx, id)
 }
 
-func (s *MongoEventService) UpdateEvent(ctx context.Context, id string, update models.Event) error {
-	return s.Repo.UpdateByID(ctx, id, update)
+// GetOccurrences lists the concrete times of an event that start in [from, to),
+// see Occurrences.
+func (s *MongoEventService) GetOccurrences(ctx context.Context, id string, from, to time.Time) (_ []models.TimeSlot, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetOccurrences")
+	defer func() { tracing.End(span, err) }()
+	event, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return Occurrences(*event, from, to)
+}
+
+func (s *MongoEventService) UpdateEvent(ctx context.Context, id string, update models.Event) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.UpdateEvent")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !CanManageEvent(user, *existing) {
+		return nil, ErrForbidden
+	}
+	if err := checkRecurrence(update); err != nil {
+		return nil, err
+	}
+	if err := checkTimeZone(update); err != nil {
+		return nil, err
+	}
+	// only organizers may hand out ownership, and an event can never be left without one
+	if !IsOrganizer(user, *existing) || len(update.Organizers) == 0 {
+		update.Organizers = existing.Organizers
+		update.CoOrganizers = existing.CoOrganizers
+	}
+	update.ID = existing.ID
+	update.Status = existing.Status
+	update.FinalSlotID = existing.FinalSlotID
+	update.ShareToken = existing.ShareToken
+	update.OrgID = existing.OrgID
+	update.DeletedAt = existing.DeletedAt // deleting goes through DeleteEvent
+	if err := s.Repo.UpdateByID(ctx, id, update); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "event updated", "eventId", id)
+	audit(ctx, s.Audit, auditEvent, models.AuditUpdated, existing.ID, existing.ID, *existing, update)
+	revise(ctx, s.Revisions, auditEvent, existing.ID, existing.ID, update)
+	return &update, nil
+}
+
+func (s *MongoEventService) DeleteEvent(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "EventService.DeleteEvent")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+	existing, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !IsOrganizer(user, *existing) {
+		return ErrForbidden
+	}
+	var answers []models.Availability
+	if s.Answers != nil {
+		// read first, DeleteMany does not say which answers it deleted
+		if answers, err = s.Answers.FindAll(ctx, query.Eq("eventId", existing.ID)); err != nil {
+			return err
+		}
+	}
+	if err := s.Repo.DeleteByID(ctx, id); err != nil {
+		return err
+	}
+	var deleted int64
+	if s.Answers != nil {
+		// after the event, so RestoreEvent can tell them from answers deleted earlier on their own
+		if deleted, err = s.Answers.DeleteMany(ctx, query.Eq("eventId", existing.ID)); err != nil {
+			return err
+		}
+	}
+	slog.InfoContext(ctx, "event deleted", "eventId", id, "answers", deleted)
+	audit(ctx, s.Audit, auditEvent, models.AuditDeleted, existing.ID, existing.ID, *existing, nil)
+	revise(ctx, s.Revisions, auditEvent, existing.ID, existing.ID, nil)
+	s.cascade(ctx, models.AuditDeleted, answers)
+	return nil
+}
+
+// cascade records the answers deleted or restored along with their event in
+// the audit and revision logs, one entry each as if done on their own.
+func (s *MongoEventService) cascade(ctx context.Context, action string, answers []models.Availability) {
+	for _, a := range answers {
+		if action == models.AuditDeleted {
+			audit(ctx, s.Audit, auditAvailability, action, a.EventID, a.ID, a, nil)
+			revise(ctx, s.Revisions, auditAvailability, a.EventID, a.ID, nil)
+		} else {
+			audit(ctx, s.Audit, auditAvailability, action, a.EventID, a.ID, nil, a)
+			revise(ctx, s.Revisions, auditAvailability, a.EventID, a.ID, a)
+		}
+	}
+}
+
+// RestoreEvent undoes DeleteEvent until the deleted event is purged, see
+// Purger. The same organizers who may delete the event may restore it.
+func (s *MongoEventService) RestoreEvent(ctx context.Context, id string) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.RestoreEvent")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.Repo.GetDeletedByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !IsOrganizer(user, *event) {
+		return nil, ErrForbidden
+	}
+	var restored []models.Availability
+	if s.Answers != nil && event.DeletedAt != nil {
+		// before the event, a failure leaves it deleted and the restore can be retried
+		if _, err = s.Answers.RestoreMany(ctx, query.Eq("eventId", event.ID), *event.DeletedAt); err != nil {
+			return nil, err
+		}
+		// nothing can be answered on a deleted event, so every live answer was just restored
+		if restored, err = s.Answers.FindAll(ctx, query.Eq("eventId", event.ID)); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Repo.RestoreByID(ctx, id); err != nil {
+		return nil, err
+	}
+	event.DeletedAt = nil
+	slog.InfoContext(ctx, "event restored", "eventId", id, "answers", len(restored))
+	audit(ctx, s.Audit, auditEvent, models.AuditRestored, event.ID, event.ID, nil, *event)
+	revise(ctx, s.Revisions, auditEvent, event.ID, event.ID, *event)
+	s.cascade(ctx, models.AuditRestored, restored)
+	return event, nil
 }
 
-func (s *MongoEventService) DeleteEvent(ctx context.Context, id string) error {
-	return s.Repo.DeleteByID(ctx, id)
+var ErrNotFinalized = errors.New("event is not finalized")
+
+// FinalizeEvent fixes the event to one of its slots and closes the poll.
+func (s *MongoEventService) FinalizeEvent(ctx context.Context, id string, slotID string) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.FinalizeEvent")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !CanManageEvent(user, *event) {
+		return nil, ErrForbidden
+	}
+	sid, err := primitive.ObjectIDFromHex(slotID)
+	if err != nil {
+		return nil, ErrInvalidSlot
+	}
+	if !slices.ContainsFunc(event.Slots, func(t models.TimeSlot) bool { return t.ID == sid }) {
+		return nil, ErrInvalidSlot
+	}
+	before := *event
+	event.Status = models.EventStatusFinalized
+	event.FinalSlotID = &sid
+	if err := s.Repo.UpdateByID(ctx, id, *event); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "event finalized", "eventId", id, "slotId", slotID)
+	audit(ctx, s.Audit, auditEvent, models.AuditFinalized, event.ID, event.ID, before, *event)
+	revise(ctx, s.Revisions, auditEvent, event.ID, event.ID, *event)
+	return event, nil
 }
 
-func (s *MongoEventService) GetAllEvents(ctx context.Context, title string) ([]models.Event, error) {
-	filter := bson.M{}
+func (s *MongoEventService) GetAllEvents(ctx context.Context, title string, opts query.FindOptions) (_ *query.Page[models.Event], err error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetAllEvents")
+	defer func() { tracing.End(span, err) }()
+	var filter query.Filter
 	if title != "" {
-		filter = bson.M{"title": bson.M{"$eq": title}}
+		filter = query.Eq("title", title)
 	}
-	return s.Repo.FindAll(ctx, filter)
+	return s.Repo.FindPage(ctx, filter, opts)
+}
+
+// ShareEvent issues a new public poll token for the event. Any previous link
+// stops working.
+func (s *MongoEventService) ShareEvent(ctx context.Context, id string) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.ShareEvent")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !CanManageEvent(user, *event) {
+		return nil, ErrForbidden
+	}
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	before := *event
+	event.ShareToken = token
+	if err := s.Repo.UpdateByID(ctx, id, *event); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "event shared", "eventId", id) // never log the token, it grants access
+	audit(ctx, s.Audit, auditEvent, models.AuditShared, event.ID, event.ID, before, *event)
+	revise(ctx, s.Revisions, auditEvent, event.ID, event.ID, *event)
+	return event, nil
+}
+
+// GetEventHistory lists the recorded changes to an event and its answers. Only
+// the event's managers may read it; admins also can once the event is deleted.
+func (s *MongoEventService) GetEventHistory(ctx context.Context, id string, opts query.FindOptions) (_ *query.Page[models.AuditEntry], err error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetEventHistory")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !user.HasRole(auth.RoleAdmin) {
+		event, err := s.Repo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !CanManageEvent(user, *event) {
+			return nil, ErrForbidden
+		}
+	}
+	eventID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Audit == nil {
+		return &query.Page[models.AuditEntry]{Items: []models.AuditEntry{}}, nil
+	}
+	return s.Audit.History(ctx, eventID, opts)
 }
 
 // ---------------- Availability ----------------
@@ -50,30 +327,328 @@ func (s *MongoEventService) GetAllEvents(ctx context.Context, title string) ([]m
 type AvailabilityService interface {
 	AddAvailability(ctx context.Context, a models.Availability) (*models.Availability, error)
 	GetAvailabilitiesByEvent(ctx context.Context, eventID string) ([]models.Availability, error)
+	ListAvailabilitiesByEvent(ctx context.Context, eventID string, opts query.FindOptions) (*query.Page[models.Availability], error)
+	StreamAvailabilitiesByEvent(ctx context.Context, eventID string) iter.Seq2[models.Availability, error]
 	DeleteAvailability(ctx context.Context, id string) error
-	UpdateAvailability(ctx context.Context, id string, a models.Availability) error
+	UpdateAvailability(ctx context.Context, id string, a models.Availability) (*models.Availability, error)
+	GetAvailabilitiesByUser(ctx context.Context, userID string) ([]models.Availability, error)
+	GetAvailabilityMatrix(ctx context.Context, eventID string) (*models.AvailabilityMatrix, error)
+	TallySlots(ctx context.Context, eventID string) (*models.SlotTallies, error)
+	TallySlotsAsOf(ctx context.Context, eventID string, at time.Time) (*models.Event, *models.SlotTallies, error)
 }
 
 type MongoAvailabilityService struct {
-	Repo repository.MongoRepository[models.Availability]
+	Repo       repository.MongoRepository[models.Availability]
+	Aggregates repository.AvailabilityAggregates
+	Events     repository.MongoRepository[models.Event]
+	Tallies    *TallyCache // optional
+	Audit      AuditLog    // optional
+	Revisions  RevisionLog // optional
+}
+
+// AddAvailability records the caller's answer for one slot of a live event.
+func (s *MongoAvailabilityService) AddAvailability(ctx context.Context, a models.Availability) (_ *models.Availability, err error) {
+	ctx, span := tracing.Start(ctx, "AvailabilityService.AddAvailability")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != user.UserID && !user.HasRole(auth.RoleAdmin) {
+		return nil, ErrForbidden
+	}
+	if err := s.checkSlot(ctx, a.EventID, a.SlotID); err != nil {
+		return nil, err
+	}
+	defer s.Tallies.Invalidate(a.EventID)
+	a.DeletedAt = nil
+	added, err := s.Repo.Insert(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "availability added", "availabilityId", added.ID.Hex(), "eventId", added.EventID.Hex(), "slotId", added.SlotID.Hex())
+	audit(ctx, s.Audit, auditAvailability, models.AuditCreated, added.EventID, added.ID, nil, *added)
+	revise(ctx, s.Revisions, auditAvailability, added.EventID, added.ID, *added)
+	return added, nil
+}
+
+// GetAvailabilitiesByEvent returns every response to event managers and only the
+// caller's own responses to everybody else.
+func (s *MongoAvailabilityService) GetAvailabilitiesByEvent(ctx context.Context, eventID string) (_ []models.Availability, err error) {
+	ctx, span := tracing.Start(ctx, "AvailabilityService.GetAvailabilitiesByEvent")
+	defer func() { tracing.End(span, err) }()
+	filter, err := s.eventFilter(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.FindAll(ctx, filter)
+}
+
+// ListAvailabilitiesByEvent is the paged form of GetAvailabilitiesByEvent.
+func (s *MongoAvailabilityService) ListAvailabilitiesByEvent(ctx context.Context, eventID string, opts query.FindOptions) (_ *query.Page[models.Availability], err error) {
+	ctx, span := tracing.Start(ctx, "AvailabilityService.ListAvailabilitiesByEvent")
+	defer func() { tracing.End(span, err) }()
+	filter, err := s.eventFilter(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.FindPage(ctx, filter, opts)
+}
+
+// StreamAvailabilitiesByEvent yields the same responses as
+// GetAvailabilitiesByEvent without loading them all at once.
+func (s *MongoAvailabilityService) StreamAvailabilitiesByEvent(ctx context.Context, eventID string) iter.Seq2[models.Availability, error] {
+	// the span covers the access check, the repository traces the iteration
+	ctx, span := tracing.Start(ctx, "AvailabilityService.StreamAvailabilitiesByEvent")
+	filter, err := s.eventFilter(ctx, eventID)
+	tracing.End(span, err)
+	if err != nil {
+		return func(yield func(models.Availability, error) bool) {
+			yield(models.Availability{}, err)
+		}
+	}
+	return s.Repo.Stream(ctx, filter)
+}
+
+// eventFilter selects the responses to eventID the caller may read.
+func (s *MongoAvailabilityService) eventFilter(ctx context.Context, eventID string) (query.Filter, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return query.Filter{}, err
+	}
+	event, err := s.Events.GetByID(ctx, eventID)
+	if err != nil {
+		return query.Filter{}, err
+	}
+	filter := query.Eq("eventId", event.ID)
+	if !CanManageEvent(user, *event) {
+		filter = query.And(filter, query.Eq("userId", user.UserID))
+	}
+	return filter, nil
+}
+
+// GetAvailabilityMatrix pivots an event's answers into a users x slots grid
+// with per-slot totals. Mongo does the grouping in one $facet query
+// (MongoRepository.Matrix), so the work here is proportional to users, not
+// answers.
+func (s *MongoAvailabilityService) GetAvailabilityMatrix(ctx context.Context, eventID string) (_ *models.AvailabilityMatrix, err error) {
+	ctx, span := tracing.Start(ctx, "AvailabilityService.GetAvailabilityMatrix")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.Events.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanManageEvent(user, *event) {
+		return nil, ErrForbidden
+	}
+	counts, err := s.Aggregates.Matrix(ctx, event.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := &models.AvailabilityMatrix{EventID: event.ID, Slots: []models.MatrixSlot{}, Rows: []models.MatrixRow{}}
+	column := map[primitive.ObjectID]int{}
+	for i, slot := range event.Slots {
+		column[slot.ID] = i
+		matrix.Slots = append(matrix.Slots, models.MatrixSlot{TimeSlot: slot})
+	}
+	for _, t := range counts.Totals {
+		if i, ok := column[t.SlotID]; ok {
+			matrix.Slots[i].Total = t.Count
+		}
+	}
+	for _, r := range counts.Rows {
+		row := models.MatrixRow{UserID: r.UserID, DisplayName: r.DisplayName, Available: make([]bool, len(event.Slots))}
+		for _, sid := range r.Slots {
+			if i, ok := column[sid]; ok {
+				row.Available[i] = true
+			}
+		}
+		matrix.Rows = append(matrix.Rows, row)
+	}
+	return matrix, nil
+}
+
+// TallySlots counts the distinct users available per slot inside Mongo, so
+// large polls are never loaded into memory row by row. Results are kept in
+// Tallies until the event's answers change.
+func (s *MongoAvailabilityService) TallySlots(ctx context.Context, eventID string) (_ *models.SlotTallies, err error) {
+	ctx, span := tracing.Start(ctx, "AvailabilityService.TallySlots")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.Events.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanManageEvent(user, *event) {
+		return nil, ErrForbidden
+	}
+	cached, version, ok := s.Tallies.get(event.ID)
+	if ok {
+		return cached, nil
+	}
+	tallies, err := s.Aggregates.TallySlots(ctx, event.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.Tallies.put(event.ID, *tallies, version)
+	return tallies, nil
 }
 
-func (s *MongoAvailabilityService) AddAvailability(ctx context.Context, a models.Availability) (*models.Availability, error) {
-	return s.Repo.Insert(ctx, a)
+// GetAvailabilitiesByUser returns every answer of a user across events. Users
+// can only list their own answers.
+func (s *MongoAvailabilityService) GetAvailabilitiesByUser(ctx context.Context, userID string) (_ []models.Availability, err error) {
+	ctx, span := tracing.Start(ctx, "AvailabilityService.GetAvailabilitiesByUser")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user.UserID != userID && !user.HasRole(auth.RoleAdmin) {
+		return nil, ErrForbidden
+	}
+	return s.Repo.FindAll(ctx, query.Eq("userId", userID))
+}
+
+func (s *MongoAvailabilityService) DeleteAvailability(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "AvailabilityService.DeleteAvailability")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+	existing, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing.UserID != user.UserID && !user.HasRole(auth.RoleAdmin) {
+		// organizers may remove responses from their own events
+		event, err := s.Events.GetByID(ctx, existing.EventID.Hex())
+		if err != nil || !CanManageEvent(user, *event) {
+			return ErrForbidden
+		}
+	}
+	defer s.Tallies.Invalidate(existing.EventID)
+	if err := s.Repo.DeleteByID(ctx, id); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "availability deleted", "availabilityId", id, "eventId", existing.EventID.Hex())
+	audit(ctx, s.Audit, auditAvailability, models.AuditDeleted, existing.EventID, existing.ID, *existing, nil)
+	revise(ctx, s.Revisions, auditAvailability, existing.EventID, existing.ID, nil)
+	return nil
 }
 
-func (s *MongoAvailabilityService) GetAvailabilitiesByEvent(ctx context.Context, eventID string) ([]models.Availability, error) {
-	eid, err := primitive.ObjectIDFromHex(eventID)
+// UpdateAvailability lets a participant move their own response to another
+// slot and returns the answer as stored.
+func (s *MongoAvailabilityService) UpdateAvailability(ctx context.Context, id string, a models.Availability) (_ *models.Availability, err error) {
+	ctx, span := tracing.Start(ctx, "AvailabilityService.UpdateAvailability")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return s.Repo.FindAll(ctx, bson.M{"eventid": eid})
+	existing, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing.UserID != user.UserID && !user.HasRole(auth.RoleAdmin) {
+		return nil, ErrForbidden
+	}
+	if err := s.checkSlot(ctx, existing.EventID, a.SlotID); err != nil {
+		return nil, err
+	}
+	a.ID = existing.ID
+	a.EventID = existing.EventID
+	a.UserID = existing.UserID
+	a.OrgID = existing.OrgID
+	a.DisplayName = existing.DisplayName
+	a.DeletedAt = existing.DeletedAt // deleting goes through DeleteAvailability
+	defer s.Tallies.Invalidate(existing.EventID)
+	if err := s.Repo.UpdateByID(ctx, id, a); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "availability updated", "availabilityId", id, "eventId", existing.EventID.Hex(), "slotId", a.SlotID.Hex())
+	audit(ctx, s.Audit, auditAvailability, models.AuditUpdated, existing.EventID, existing.ID, *existing, a)
+	revise(ctx, s.Revisions, auditAvailability, existing.EventID, existing.ID, a)
+	return &a, nil
+}
+
+// checkSlot fails with ErrInvalidSlot unless slotID is one of the slots of the
+// live event eventID.
+func (s *MongoAvailabilityService) checkSlot(ctx context.Context, eventID, slotID primitive.ObjectID) error {
+	event, err := s.Events.GetByID(ctx, eventID.Hex())
+	if err != nil {
+		return err
+	}
+	_, err = slotsOf(*event, []string{slotID.Hex()})
+	return err
 }
 
-func (s *MongoAvailabilityService) DeleteAvailability(ctx context.Context, id string) error {
-	return s.Repo.DeleteByID(ctx, id)
+func slotsOf(event models.Event, slotIDs []string) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(slotIDs))
+	for _, raw := range slotIDs {
+		sid, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			return nil, ErrInvalidSlot
+		}
+		if !slices.ContainsFunc(event.Slots, func(t models.TimeSlot) bool { return t.ID == sid }) {
+			return nil, ErrInvalidSlot
+		}
+		if !slices.Contains(ids, sid) {
+			ids = append(ids, sid)
+		}
+	}
+	return ids, nil
 }
 
-func (s *MongoAvailabilityService) UpdateAvailability(ctx context.Context, id string, a models.Availability) error {
-	return s.Repo.UpdateByID(ctx, id, a)
+// SetAnswers makes the caller's availability for event exactly slotIDs, going
+// through svc so the usual ownership checks apply.
+func SetAnswers(ctx context.Context, svc AvailabilityService, event models.Event, slotIDs []string) ([]models.Availability, error) {
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := slotsOf(event, slotIDs)
+	if err != nil {
+		return nil, err
+	}
+	current, err := svc.GetAvailabilitiesByEvent(ctx, event.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	answers := []models.Availability{}
+	for _, a := range current {
+		if a.UserID != user.UserID {
+			continue // organizers see everybody's answers
+		}
+		if slices.Contains(slots, a.SlotID) {
+			answers = append(answers, a)
+			slots = slices.DeleteFunc(slots, func(id primitive.ObjectID) bool { return id == a.SlotID })
+			continue
+		}
+		if err := svc.DeleteAvailability(ctx, a.ID.Hex()); err != nil {
+			return nil, err
+		}
+	}
+	for _, sid := range slots {
+		created, err := svc.AddAvailability(ctx, models.Availability{
+			ID:          primitive.NewObjectID(),
+			EventID:     event.ID,
+			SlotID:      sid,
+			UserID:      user.UserID,
+			DisplayName: user.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, *created)
+	}
+	return answers, nil
 }
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/metrics"
+	"github.com/chetanugale/scheduling-system/mocker"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func ctxAs(userID string, roles ...string) context.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	auth.SetIdentity(c, auth.Identity{UserID: userID, Roles: roles})
+	return c
+}
+
+func TestCreateEventAddsCreatorAsOrganizer(t *testing.T) {
+	repo := new(mocker.MockRepo[models.Event])
+	svc := &MongoEventService{Repo: repo}
+
+	repo.On("Insert", mock.Anything, mock.MatchedBy(func(e models.Event) bool {
+		return len(e.Organizers) == 1 && e.Organizers[0] == "alice" && e.Status == models.EventStatusOpen && !e.ID.IsZero()
+	})).Return(&models.Event{}, nil)
+
+	_, err := svc.CreateEvent(ctxAs("alice"), models.Event{Title: "sync"})
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+
+	_, err = svc.CreateEvent(context.Background(), models.Event{Title: "sync"})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestEventRoles(t *testing.T) {
+	id := primitive.NewObjectID()
+	event := &models.Event{ID: id, Organizers: []string{"owner"}, CoOrganizers: []string{"co"}}
+
+	repo := new(mocker.MockRepo[models.Event])
+	repo.On("GetByID", mock.Anything, id.Hex()).Return(event, nil)
+	repo.On("UpdateByID", mock.Anything, id.Hex(), mock.Anything).Return(nil)
+	repo.On("DeleteByID", mock.Anything, id.Hex()).Return(nil)
+	svc := &MongoEventService{Repo: repo}
+
+	_, err := svc.UpdateEvent(ctxAs("guest"), id.Hex(), models.Event{})
+	assert.ErrorIs(t, err, ErrForbidden)
+	updated, err := svc.UpdateEvent(ctxAs("co"), id.Hex(), models.Event{Organizers: []string{"co"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"owner"}, updated.Organizers)
+	repo.AssertCalled(t, "UpdateByID", mock.Anything, id.Hex(), mock.MatchedBy(func(e models.Event) bool {
+		return len(e.Organizers) == 1 && e.Organizers[0] == "owner" // co-organizers cannot take ownership
+	}))
+
+	assert.ErrorIs(t, svc.DeleteEvent(ctxAs("co"), id.Hex()), ErrForbidden)
+	assert.NoError(t, svc.DeleteEvent(ctxAs("root", auth.RoleAdmin), id.Hex()))
+}
+
+func TestSpansRecordErrors(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	id := primitive.NewObjectID()
+	repo := new(mocker.MockRepo[models.Event])
+	repo.On("GetByID", mock.Anything, id.Hex()).Return(&models.Event{ID: id, Organizers: []string{"owner"}}, nil)
+	svc := &MongoEventService{Repo: repo}
+
+	_, err := svc.GetEvent(ctxAs("owner"), id.Hex())
+	assert.NoError(t, err)
+	assert.ErrorIs(t, svc.DeleteEvent(ctxAs("guest"), id.Hex()), ErrForbidden)
+
+	spans := rec.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, "EventService.DeleteEvent", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, ErrForbidden.Error(), spans[1].Status().Description)
+}
+
+func TestAvailabilityOwnership(t *testing.T) {
+	eventID := primitive.NewObjectID()
+	availID := primitive.NewObjectID()
+	event := &models.Event{ID: eventID, Organizers: []string{"owner"}}
+	slotID := primitive.NewObjectID()
+	event.Slots = []models.TimeSlot{{ID: slotID}}
+	row := &models.Availability{ID: availID, EventID: eventID, UserID: "alice", SlotID: slotID}
+
+	events := new(mocker.MockRepo[models.Event])
+	events.On("GetByID", mock.Anything, eventID.Hex()).Return(event, nil)
+	repo := new(mocker.MockRepo[models.Availability])
+	repo.On("GetByID", mock.Anything, availID.Hex()).Return(row, nil)
+	repo.On("UpdateByID", mock.Anything, availID.Hex(), mock.Anything).Return(nil)
+	repo.On("DeleteByID", mock.Anything, availID.Hex()).Return(nil)
+	repo.On("FindAll", mock.Anything, mock.Anything).Return([]models.Availability{*row}, nil)
+	svc := &MongoAvailabilityService{Repo: repo, Events: events}
+
+	_, err := svc.UpdateAvailability(ctxAs("bob"), availID.Hex(), models.Availability{})
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.ErrorIs(t, svc.DeleteAvailability(ctxAs("bob"), availID.Hex()), ErrForbidden)
+	updated, err := svc.UpdateAvailability(ctxAs("alice"), availID.Hex(), models.Availability{UserID: "bob", SlotID: slotID})
+	assert.NoError(t, err)
+	assert.Equal(t, models.Availability{ID: availID, EventID: eventID, UserID: "alice", SlotID: slotID}, *updated)
+	// answers only move between the event's own slots
+	_, err = svc.UpdateAvailability(ctxAs("alice"), availID.Hex(), models.Availability{SlotID: primitive.NewObjectID()})
+	assert.ErrorIs(t, err, ErrInvalidSlot)
+	_, err = svc.UpdateAvailability(ctxAs("alice"), availID.Hex(), models.Availability{})
+	assert.ErrorIs(t, err, ErrInvalidSlot)
+	repo.AssertCalled(t, "UpdateByID", mock.Anything, availID.Hex(), mock.MatchedBy(func(a models.Availability) bool {
+		return a.UserID == "alice"
+	}))
+	assert.NoError(t, svc.DeleteAvailability(ctxAs("owner"), availID.Hex()))
+
+	_, err = svc.GetAvailabilitiesByEvent(ctxAs("bob"), eventID.Hex())
+	assert.NoError(t, err)
+	repo.AssertCalled(t, "FindAll", mock.Anything, query.And(query.Eq("eventId", eventID), query.Eq("userId", "bob")))
+
+	repo.On("FindPage", mock.Anything, mock.Anything, mock.Anything).Return(&query.Page[models.Availability]{}, nil)
+	_, err = svc.ListAvailabilitiesByEvent(ctxAs("owner"), eventID.Hex(), query.FindOptions{Limit: 10})
+	assert.NoError(t, err)
+	repo.AssertCalled(t, "FindPage", mock.Anything, query.Eq("eventId", eventID), query.FindOptions{Limit: 10})
+
+	repo.On("Stream", mock.Anything, query.And(query.Eq("eventId", eventID), query.Eq("userId", "bob"))).Return([]models.Availability{}, nil)
+	for _, err := range svc.StreamAvailabilitiesByEvent(ctxAs("bob"), eventID.Hex()) {
+		assert.NoError(t, err)
+	}
+	for _, err := range svc.StreamAvailabilitiesByEvent(context.Background(), eventID.Hex()) {
+		assert.ErrorIs(t, err, ErrUnauthenticated)
+	}
+	repo.AssertCalled(t, "Stream", mock.Anything, query.And(query.Eq("eventId", eventID), query.Eq("userId", "bob")))
+}
+
+func TestGetAvailabilityMatrix(t *testing.T) {
+	eventID := primitive.NewObjectID()
+	slotA, slotB := primitive.NewObjectID(), primitive.NewObjectID()
+	event := &models.Event{ID: eventID, Organizers: []string{"owner"}, Slots: []models.TimeSlot{{ID: slotA}, {ID: slotB}}}
+
+	events := new(mocker.MockRepo[models.Event])
+	events.On("GetByID", mock.Anything, eventID.Hex()).Return(event, nil)
+
+	counts := &models.MatrixCounts{
+		Rows:   []models.UserSlots{{UserID: "alice", Slots: []primitive.ObjectID{slotB}}},
+		Totals: []models.SlotCount{{SlotID: slotB, Count: 1}},
+	}
+
+	aggregates := new(mocker.MockAggregates)
+	aggregates.On("Matrix", mock.Anything, eventID).Return(counts, nil)
+	svc := &MongoAvailabilityService{Aggregates: aggregates, Events: events}
+
+	m, err := svc.GetAvailabilityMatrix(ctxAs("owner"), eventID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1}, []int{m.Slots[0].Total, m.Slots[1].Total})
+	assert.Equal(t, []bool{false, true}, m.Rows[0].Available)
+
+	_, err = svc.GetAvailabilityMatrix(ctxAs("alice"), eventID.Hex())
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestTallySlots(t *testing.T) {
+	eventID := primitive.NewObjectID()
+	slotID := primitive.NewObjectID()
+	events := new(mocker.MockRepo[models.Event])
+	events.On("GetByID", mock.Anything, eventID.Hex()).Return(&models.Event{ID: eventID, Organizers: []string{"owner"}}, nil)
+
+	counted := &models.SlotTallies{
+		Slots: []models.SlotTally{{SlotID: slotID, Users: []string{"a"}, Count: 1}},
+		Users: []string{"a", "b"},
+	}
+	aggregates := new(mocker.MockAggregates)
+	aggregates.On("TallySlots", mock.Anything, eventID).Return(counted, nil)
+	svc := &MongoAvailabilityService{Aggregates: aggregates, Events: events}
+
+	tallies, err := svc.TallySlots(ctxAs("owner"), eventID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, tallies.Users)
+	assert.Equal(t, 1, tallies.Slots[0].Count)
+
+	_, err = svc.TallySlots(ctxAs("a"), eventID.Hex())
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestTallySlotsCache(t *testing.T) {
+	eventID := primitive.NewObjectID()
+	events := new(mocker.MockRepo[models.Event])
+	slotID := primitive.NewObjectID()
+	events.On("GetByID", mock.Anything, eventID.Hex()).Return(&models.Event{ID: eventID, Organizers: []string{"owner"}, Slots: []models.TimeSlot{{ID: slotID}}}, nil)
+	repo := new(mocker.MockRepo[models.Availability])
+	repo.On("Insert", mock.Anything, mock.Anything).Return(&models.Availability{}, nil)
+	aggregates := new(mocker.MockAggregates)
+	aggregates.On("TallySlots", mock.Anything, eventID).Return(&models.SlotTallies{}, nil)
+	svc := &MongoAvailabilityService{Repo: repo, Aggregates: aggregates, Events: events, Tallies: NewTallyCache(10, time.Minute)}
+
+	for range 3 {
+		_, err := svc.TallySlots(ctxAs("owner"), eventID.Hex())
+		assert.NoError(t, err)
+	}
+	aggregates.AssertNumberOfCalls(t, "TallySlots", 1)
+
+	_, err := svc.AddAvailability(ctxAs("alice"), models.Availability{EventID: eventID, SlotID: slotID, UserID: "alice"})
+	assert.NoError(t, err)
+	_, err = svc.TallySlots(ctxAs("owner"), eventID.Hex())
+	assert.NoError(t, err)
+	aggregates.AssertNumberOfCalls(t, "TallySlots", 2)
+
+	_, err = svc.TallySlots(ctxAs("a"), eventID.Hex()) // cached tallies are still checked for access
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestTallyCacheRefusesStaleTallies(t *testing.T) {
+	cache := NewTallyCache(10, time.Minute)
+	eventID := primitive.NewObjectID()
+
+	_, version, ok := cache.get(eventID)
+	assert.False(t, ok)
+	// an answer changes while the tallies are being read
+	cache.Invalidate(eventID)
+	cache.put(eventID, models.SlotTallies{Users: []string{"stale"}}, version)
+	_, _, ok = cache.get(eventID)
+	assert.False(t, ok, "tallies read before the change are not stored")
+
+	_, version, _ = cache.get(eventID)
+	cache.put(eventID, models.SlotTallies{Users: []string{"fresh"}}, version)
+	cached, _, ok := cache.get(eventID)
+	assert.True(t, ok)
+	cached.Users[0] = "changed by the caller"
+	cached, _, _ = cache.get(eventID)
+	assert.Equal(t, []string{"fresh"}, cached.Users)
+}
+
+func TestStatsRefresh(t *testing.T) {
+	events := new(mocker.MockRepo[models.Event])
+	avail := new(mocker.MockRepo[models.Availability])
+	events.On("CountDocuments", mock.Anything, query.Eq("status", models.EventStatusOpen)).Return(int64(4), nil)
+	avail.On("CountDocuments", mock.Anything, mock.MatchedBy(func(f query.Filter) bool {
+		from, ok := f.From.(primitive.ObjectID)
+		return f.Op == query.OpRange && f.Field == "id" && ok && time.Since(from.Timestamp()) <= time.Hour+time.Second
+	})).Return(int64(9), nil)
+
+	stats := &Stats{Events: events, Avail: avail}
+	assert.NoError(t, stats.Refresh(context.Background()))
+
+	resp := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(resp, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, resp.Body.String(), "scheduling_open_polls 4")
+	assert.Contains(t, resp.Body.String(), "scheduling_responses_last_hour 9")
+}
+
+func TestRestoreEvent(t *testing.T) {
+	id := primitive.NewObjectID()
+	deletedAt := time.Now()
+	repo := new(mocker.MockRepo[models.Event])
+	repo.On("GetDeletedByID", mock.Anything, id.Hex()).Return(&models.Event{ID: id, Organizers: []string{"owner"}, CoOrganizers: []string{"co"}, DeletedAt: &deletedAt}, nil)
+	repo.On("RestoreByID", mock.Anything, id.Hex()).Return(nil)
+	answers := new(mocker.MockRepo[models.Availability])
+	answers.On("RestoreMany", mock.Anything, query.Eq("eventId", id), deletedAt).Return(int64(2), nil)
+	answers.On("FindAll", mock.Anything, query.Eq("eventId", id)).Return([]models.Availability{
+		{ID: primitive.NewObjectID(), EventID: id, UserID: "alice"},
+		{ID: primitive.NewObjectID(), EventID: id, UserID: "bob"},
+	}, nil)
+	audit := &memoryAudit{}
+	svc := &MongoEventService{Repo: repo, Answers: answers, Audit: audit}
+
+	_, err := svc.RestoreEvent(ctxAs("co"), id.Hex())
+	assert.ErrorIs(t, err, ErrForbidden)
+	repo.AssertNotCalled(t, "RestoreByID", mock.Anything, mock.Anything)
+
+	event, err := svc.RestoreEvent(ctxAs("owner"), id.Hex())
+	assert.NoError(t, err)
+	assert.Nil(t, event.DeletedAt)
+	repo.AssertCalled(t, "RestoreByID", mock.Anything, id.Hex())
+	answers.AssertExpectations(t) // only those deleted with the event
+	if assert.Len(t, *audit, 3) {
+		assert.Equal(t, models.AuditRestored, (*audit)[0].Action)
+		for _, e := range (*audit)[1:] {
+			assert.Equal(t, auditAvailability, e.Entity)
+			assert.Equal(t, models.AuditRestored, e.Action)
+		}
+	}
+}
+
+func TestDeleteEventDeletesAnswers(t *testing.T) {
+	id := primitive.NewObjectID()
+	repo := new(mocker.MockRepo[models.Event])
+	repo.On("GetByID", mock.Anything, id.Hex()).Return(&models.Event{ID: id, Organizers: []string{"owner"}}, nil)
+	repo.On("DeleteByID", mock.Anything, id.Hex()).Return(nil)
+	answerIDs := []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID()}
+	answers := new(mocker.MockRepo[models.Availability])
+	answers.On("FindAll", mock.Anything, query.Eq("eventId", id)).Return([]models.Availability{
+		{ID: answerIDs[0], EventID: id, UserID: "alice"},
+		{ID: answerIDs[1], EventID: id, UserID: "bob"},
+	}, nil)
+	answers.On("DeleteMany", mock.Anything, query.Eq("eventId", id)).Return(int64(2), nil)
+	revisions := new(mocker.MockRepo[models.Revision])
+	revisions.On("Insert", mock.Anything, mock.Anything).Return(&models.Revision{}, nil)
+	audit := &memoryAudit{}
+	svc := &MongoEventService{Repo: repo, Answers: answers, Audit: audit, Revisions: &MongoRevisionLog{Repo: revisions}}
+
+	assert.NoError(t, svc.DeleteEvent(ctxAs("owner"), id.Hex()))
+	repo.AssertExpectations(t)
+	answers.AssertExpectations(t)
+
+	// each answer deleted along with the event is in the history as if deleted on its own
+	if assert.Len(t, *audit, 3) {
+		assert.Equal(t, auditEvent, (*audit)[0].Entity)
+		for i, e := range (*audit)[1:] {
+			assert.Equal(t, auditAvailability, e.Entity)
+			assert.Equal(t, answerIDs[i], e.EntityID)
+			assert.Equal(t, models.AuditDeleted, e.Action)
+			assert.Equal(t, "owner", e.Actor)
+		}
+	}
+	for _, answerID := range answerIDs {
+		revisions.AssertCalled(t, "Insert", mock.Anything, mock.MatchedBy(func(r models.Revision) bool {
+			return r.EntityID == answerID && r.Entity == auditAvailability && r.Deleted
+		}))
+	}
+}
+
+func TestAddAvailabilityChecksEvent(t *testing.T) {
+	eventID, slotID := primitive.NewObjectID(), primitive.NewObjectID()
+	events := new(mocker.MockRepo[models.Event])
+	events.On("GetByID", mock.Anything, eventID.Hex()).Return(&models.Event{ID: eventID, Slots: []models.TimeSlot{{ID: slotID}}}, nil)
+	events.On("GetByID", mock.Anything, mock.Anything).Return((*models.Event)(nil), mongo.ErrNoDocuments) // missing or deleted
+	repo := new(mocker.MockRepo[models.Availability])
+	repo.On("Insert", mock.Anything, mock.Anything).Return(&models.Availability{EventID: eventID, SlotID: slotID}, nil)
+	svc := &MongoAvailabilityService{Repo: repo, Events: events}
+
+	_, err := svc.AddAvailability(ctxAs("alice"), models.Availability{EventID: primitive.NewObjectID(), SlotID: slotID, UserID: "alice"})
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	_, err = svc.AddAvailability(ctxAs("alice"), models.Availability{EventID: eventID, SlotID: primitive.NewObjectID(), UserID: "alice"})
+	assert.ErrorIs(t, err, ErrInvalidSlot)
+	_, err = svc.AddAvailability(ctxAs("alice"), models.Availability{EventID: eventID, SlotID: slotID, UserID: "bob"})
+	assert.ErrorIs(t, err, ErrForbidden)
+	repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+
+	_, err = svc.AddAvailability(ctxAs("alice"), models.Availability{EventID: eventID, SlotID: slotID, UserID: "alice"})
+	assert.NoError(t, err)
+}
+
+func TestUpdateCannotDelete(t *testing.T) {
+	// deletedAt is not part of the API
+	var update models.Event
+	assert.NoError(t, json.Unmarshal([]byte(`{"title":"sync","deletedAt":"2000-01-01T00:00:00Z"}`), &update))
+	assert.Nil(t, update.DeletedAt)
+
+	// nor can a service caller set it through an update
+	id := primitive.NewObjectID()
+	events := new(mocker.MockRepo[models.Event])
+	slotID := primitive.NewObjectID()
+	events.On("GetByID", mock.Anything, id.Hex()).Return(&models.Event{ID: id, Organizers: []string{"owner"}, CoOrganizers: []string{"co"}, Slots: []models.TimeSlot{{ID: slotID}}}, nil)
+	events.On("UpdateByID", mock.Anything, id.Hex(), mock.MatchedBy(func(e models.Event) bool { return e.DeletedAt == nil })).Return(nil)
+	long := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc := &MongoEventService{Repo: events}
+	_, err := svc.UpdateEvent(ctxAs("co"), id.Hex(), models.Event{Title: "sync", DeletedAt: &long})
+	assert.NoError(t, err)
+	events.AssertExpectations(t)
+
+	answerID := primitive.NewObjectID()
+	avail := new(mocker.MockRepo[models.Availability])
+	avail.On("GetByID", mock.Anything, answerID.Hex()).Return(&models.Availability{ID: answerID, EventID: id, UserID: "alice"}, nil)
+	avail.On("UpdateByID", mock.Anything, answerID.Hex(), mock.MatchedBy(func(a models.Availability) bool { return a.DeletedAt == nil })).Return(nil)
+	availSvc := &MongoAvailabilityService{Repo: avail, Events: events}
+	_, err = availSvc.UpdateAvailability(ctxAs("alice"), answerID.Hex(), models.Availability{SlotID: slotID, DeletedAt: &long})
+	assert.NoError(t, err)
+	avail.AssertExpectations(t)
+}
+
+func TestPurge(t *testing.T) {
+	events := new(mocker.MockRepo[models.Event])
+	avail := new(mocker.MockRepo[models.Availability])
+	retained := mock.MatchedBy(func(cutoff time.Time) bool {
+		return time.Since(cutoff) >= 24*time.Hour && time.Since(cutoff) < 24*time.Hour+time.Minute
+	})
+	events.On("PurgeDeleted", mock.Anything, query.Filter{}, retained).Return(int64(1), nil)
+	avail.On("PurgeDeleted", mock.Anything, query.Filter{}, retained).Return(int64(3), nil)
+
+	purger := &Purger{Events: events, Avail: avail, Retention: 24 * time.Hour}
+	assert.NoError(t, purger.Purge(context.Background()))
+	events.AssertExpectations(t)
+	avail.AssertExpectations(t)
+}
+
+func TestTallySlotsAsOf(t *testing.T) {
+	eventID := primitive.NewObjectID()
+	slotA, slotB := primitive.NewObjectID(), primitive.NewObjectID()
+	events := new(mocker.MockRepo[models.Event])
+	events.On("GetByID", mock.Anything, eventID.Hex()).Return(&models.Event{ID: eventID, Title: "renamed", Organizers: []string{"owner"}}, nil)
+
+	at := time.Now().Add(-time.Hour)
+	revisions := new(mocker.MockRevisionHistory)
+	revisions.On("LatestRevisions", mock.Anything, eventID, at).Return([]models.Revision{
+		{Entity: "event", Event: &models.Event{ID: eventID, Title: "original"}},
+		{Entity: "availability", Availability: &models.Availability{UserID: "bob", SlotID: slotB}},
+		{Entity: "availability", Availability: &models.Availability{UserID: "alice", SlotID: slotB}},
+		{Entity: "availability", Availability: &models.Availability{UserID: "alice", SlotID: slotA}},
+		{Entity: "availability", Deleted: true},
+	}, nil)
+	svc := &MongoAvailabilityService{Events: events, Revisions: &MongoRevisionLog{History: revisions}}
+
+	event, tallies, err := svc.TallySlotsAsOf(ctxAs("owner"), eventID.Hex(), at)
+	assert.NoError(t, err)
+	assert.Equal(t, "original", event.Title)
+	assert.Equal(t, []string{"alice", "bob"}, tallies.Users)
+	assert.Equal(t, []models.SlotTally{
+		{SlotID: slotB, Users: []string{"alice", "bob"}, Count: 2},
+		{SlotID: slotA, Users: []string{"alice"}, Count: 1},
+	}, tallies.Slots)
+
+	_, _, err = svc.TallySlotsAsOf(ctxAs("bob"), eventID.Hex(), at)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	revisions.On("LatestRevisions", mock.Anything, eventID, at.Add(-time.Hour)).Return([]models.Revision{}, nil)
+	_, _, err = svc.TallySlotsAsOf(ctxAs("owner"), eventID.Hex(), at.Add(-time.Hour))
+	assert.ErrorIs(t, err, ErrNoRevision)
+}
+
+func TestReviseDropsShareToken(t *testing.T) {
+	revisions := new(mocker.MockRepo[models.Revision])
+	revisions.On("Insert", mock.Anything, mock.Anything).Return(&models.Revision{}, nil)
+	log := &MongoRevisionLog{Repo: revisions}
+	id := primitive.NewObjectID()
+
+	revise(context.Background(), log, auditEvent, id, id, models.Event{ID: id, ShareToken: "secret"})
+	revise(context.Background(), log, auditEvent, id, id, nil)
+
+	revisions.AssertCalled(t, "Insert", mock.Anything, mock.MatchedBy(func(r models.Revision) bool {
+		return r.Event != nil && r.Event.ShareToken == "" && !r.Deleted
+	}))
+	revisions.AssertCalled(t, "Insert", mock.Anything, mock.MatchedBy(func(r models.Revision) bool {
+		return r.Event == nil && r.Deleted
+	}))
+}
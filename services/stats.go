@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/metrics"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/chetanugale/scheduling-system/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Stats computes the business gauges exported by package metrics. The counts
+// span every organization, so the repositories must not be tenant scoped.
+type Stats struct {
+	Events repository.MongoRepository[models.Event]
+	Avail  repository.MongoRepository[models.Availability]
+}
+
+// Refresh recounts open polls and the answers created during the last hour,
+// going by the creation time embedded in their ids.
+func (s *Stats) Refresh(ctx context.Context) error {
+	open, err := s.Events.CountDocuments(ctx, query.Eq("status", models.EventStatusOpen))
+	if err != nil {
+		return err
+	}
+	hourAgo := primitive.NewObjectIDFromTimestamp(time.Now().Add(-time.Hour))
+	recent, err := s.Avail.CountDocuments(ctx, query.Range("id", hourAgo, nil))
+	if err != nil {
+		return err
+	}
+	metrics.SetBusiness(open, recent)
+	return nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TallyCache keeps the slot tallies behind an event's recommendation between
+// availability changes, so dashboards polling /events/:id/recommend do not
+// rerun the aggregation on every hit. MongoAvailabilityService drops an
+// event's entry whenever one of its answers is added, changed or removed; the
+// TTL bounds staleness from writes made by other instances.
+//
+// A tally computed while an answer changes could be stored after the
+// change invalidated the entry, and then served until the TTL. Invalidate
+// bumps a version for that: put only stores tallies read at the current one.
+type TallyCache struct {
+	lru     *expirable.LRU[primitive.ObjectID, models.SlotTallies]
+	mu      sync.Mutex
+	version uint64
+}
+
+func NewTallyCache(size int, ttl time.Duration) *TallyCache {
+	return &TallyCache{lru: expirable.NewLRU[primitive.ObjectID, models.SlotTallies](size, nil, ttl)}
+}
+
+// cloneTallies copies t, the cache and its callers never share slices.
+func cloneTallies(t models.SlotTallies) models.SlotTallies {
+	out := models.SlotTallies{Slots: make([]models.SlotTally, len(t.Slots)), Users: slices.Clone(t.Users)}
+	for i, s := range t.Slots {
+		s.Users = slices.Clone(s.Users)
+		out.Slots[i] = s
+	}
+	return out
+}
+
+// get, put and Invalidate tolerate a nil cache, which disables caching. On a
+// miss, get returns the version to hand to put once the tallies are read.
+func (c *TallyCache) get(eventID primitive.ObjectID) (*models.SlotTallies, uint64, bool) {
+	if c == nil {
+		return nil, 0, false
+	}
+	c.mu.Lock()
+	version := c.version
+	c.mu.Unlock()
+	t, ok := c.lru.Get(eventID)
+	if !ok {
+		return nil, version, false
+	}
+	t = cloneTallies(t)
+	return &t, version, true
+}
+
+// put stores t unless some answer changed since version was read.
+func (c *TallyCache) put(eventID primitive.ObjectID, t models.SlotTallies, version uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version == version {
+		c.lru.Add(eventID, cloneTallies(t))
+	}
+}
+
+// Invalidate forgets the tallies of an event. Call it after the change is
+// written, tallies read before then are refused by put.
+func (c *TallyCache) Invalidate(eventID primitive.ObjectID) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	c.lru.Remove(eventID)
+}
+
+// Tally counts answers already in memory the way TallySlots does inside
+// Mongo, for answers that were not read from the collection: a past state
+// replayed from revisions, or a page of answers at hand.
+func Tally(answers []models.Availability) *models.SlotTallies {
+	users := map[string]bool{}
+	slots := map[primitive.ObjectID]map[string]bool{}
+	for _, a := range answers {
+		users[a.UserID] = true
+		if slots[a.SlotID] == nil {
+			slots[a.SlotID] = map[string]bool{}
+		}
+		slots[a.SlotID][a.UserID] = true
+	}
+	tallies := &models.SlotTallies{Slots: []models.SlotTally{}, Users: sortedKeys(users)}
+	for id, who := range slots {
+		tallies.Slots = append(tallies.Slots, models.SlotTally{SlotID: id, Users: sortedKeys(who), Count: len(who)})
+	}
+	slices.SortFunc(tallies.Slots, func(a, b models.SlotTally) int {
+		return cmp.Or(b.Count-a.Count, cmp.Compare(a.SlotID.Hex(), b.SlotID.Hex()))
+	})
+	return tallies
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/chetanugale/scheduling-system/repository"
+	"github.com/chetanugale/scheduling-system/tenant"
+	"github.com/chetanugale/scheduling-system/tracing"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidTimeZone  = errors.New("unknown time zone")
+	ErrInvalidWorkHours = errors.New("working hours must be HH:MM, the start before the end")
+	ErrInvalidFeedToken = errors.New("invalid calendar feed token")
+)
+
+// Working day of a user who has not set theirs.
+const (
+	DefaultWorkStart = "09:00"
+	DefaultWorkEnd   = "17:00"
+)
+
+// LoadZone resolves an IANA zone name such as "Asia/Kolkata". The empty name
+// and "Local" are rejected, the server's own zone means nothing to its users.
+func LoadZone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("%w %q", ErrInvalidTimeZone, name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q", ErrInvalidTimeZone, name)
+	}
+	return loc, nil
+}
+
+// checkTimeZone rejects an event zone that cannot be loaded. Events may have none.
+func checkTimeZone(e models.Event) error {
+	if e.TimeZone == "" {
+		return nil
+	}
+	_, err := LoadZone(e.TimeZone)
+	return err
+}
+
+// DefaultProfile is the profile of a user who never saved one.
+func DefaultProfile(userID string) models.User {
+	return models.User{UserID: userID, WorkStart: DefaultWorkStart, WorkEnd: DefaultWorkEnd}
+}
+
+// clock parses a "15:04" time of day into minutes after midnight.
+func clock(v string) (int, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, ErrInvalidWorkHours
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// workingDay returns the start and end of the working day of p, in minutes
+// after local midnight.
+func workingDay(p models.User) (int, int, error) {
+	start, err := clock(p.WorkStart)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := clock(p.WorkEnd)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end <= start {
+		return 0, 0, ErrInvalidWorkHours
+	}
+	return start, end, nil
+}
+
+// OutsideWorkingHours lists, per slot id, the users for whom the slot does
+// not fit in their local working day. Profiles without a zone are skipped,
+// there is no local time to judge them by.
+func OutsideWorkingHours(slots []models.TimeSlot, profiles []models.User) map[string][]string {
+	outside := map[string][]string{}
+	for _, p := range profiles {
+		loc, err := LoadZone(p.TimeZone)
+		if err != nil {
+			continue
+		}
+		start, end, err := workingDay(p)
+		if err != nil {
+			continue
+		}
+		for _, slot := range slots {
+			from := slot.StartTime.In(loc)
+			// time.Date rather than adding minutes, days with a DST change are not 24h long
+			y, m, d := from.Date()
+			dayStart := time.Date(y, m, d, start/60, start%60, 0, 0, loc)
+			dayEnd := time.Date(y, m, d, end/60, end%60, 0, 0, loc)
+			if from.Before(dayStart) || slot.EndTime.In(loc).After(dayEnd) {
+				outside[slot.ID.Hex()] = append(outside[slot.ID.Hex()], p.UserID)
+			}
+		}
+	}
+	return outside
+}
+
+// UserService keeps the profiles of the users of an organization: their zone
+// and working hours. It also issues the secret URL calendar clients read a
+// user's feed with, they cannot send API credentials.
+type UserService interface {
+	GetProfile(ctx context.Context, userID string) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID string, profile models.User) (*models.User, error)
+	GetProfiles(ctx context.Context, userIDs []string) ([]models.User, error)
+	RotateFeedToken(ctx context.Context, userID string) (string, error)
+	RevokeFeedToken(ctx context.Context, userID string) error
+	OpenFeed(ctx context.Context, feedToken string) (context.Context, string, error)
+}
+
+type MongoUserService struct {
+	Repo repository.MongoRepository[models.User]
+	// Feeds must not be tenant scoped: feed tokens are resolved before the
+	// organization is known.
+	Feeds repository.MongoRepository[models.User]
+}
+
+// find returns the stored profile of userID, nil when there is none.
+func (s *MongoUserService) find(ctx context.Context, userID string) (*models.User, error) {
+	found, err := s.Repo.FindAll(ctx, query.Eq("userId", userID))
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	return &found[0], nil
+}
+
+// GetProfile returns the profile of userID, DefaultProfile when they have not
+// saved one. Any member of the organization may read it, to schedule around it.
+func (s *MongoUserService) GetProfile(ctx context.Context, userID string) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetProfile")
+	defer func() { tracing.End(span, err) }()
+	if _, err := caller(ctx); err != nil {
+		return nil, err
+	}
+	profile, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		p := DefaultProfile(userID)
+		return &p, nil
+	}
+	return profile, nil
+}
+
+// UpdateProfile replaces the profile of userID. Users edit their own, admins
+// anybody's. Empty working hours are reset to the defaults.
+func (s *MongoUserService) UpdateProfile(ctx context.Context, userID string, profile models.User) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateProfile")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user.UserID != userID && !user.HasRole(auth.RoleAdmin) {
+		return nil, ErrForbidden
+	}
+	if profile.TimeZone != "" {
+		if _, err := LoadZone(profile.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+	if profile.WorkStart == "" && profile.WorkEnd == "" {
+		profile.WorkStart, profile.WorkEnd = DefaultWorkStart, DefaultWorkEnd
+	}
+	if _, _, err := workingDay(profile); err != nil {
+		return nil, err
+	}
+	profile.UserID = userID
+	existing, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		profile.ID = primitive.NewObjectID()
+		created, err := s.Repo.Insert(ctx, profile)
+		if err != nil {
+			return nil, err
+		}
+		slog.InfoContext(ctx, "profile created", "userId", userID, "timeZone", created.TimeZone)
+		return created, nil
+	}
+	profile.ID = existing.ID
+	profile.FeedTokenHash = existing.FeedTokenHash // changed through RotateFeedToken
+	if err := s.Repo.UpdateByID(ctx, existing.ID.Hex(), profile); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "profile updated", "userId", userID, "timeZone", profile.TimeZone)
+	return &profile, nil
+}
+
+// GetProfiles returns the stored profiles of the given users. Users without
+// one are missing from the result.
+func (s *MongoUserService) GetProfiles(ctx context.Context, userIDs []string) (_ []models.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetProfiles")
+	defer func() { tracing.End(span, err) }()
+	if _, err := caller(ctx); err != nil {
+		return nil, err
+	}
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	return s.Repo.FindAll(ctx, query.In("userId", userIDs...))
+}
+
+// setFeedToken stores hash as the feed token of userID, saving a default
+// profile if they have none.
+func (s *MongoUserService) setFeedToken(ctx context.Context, userID string, hash string) error {
+	profile, err := s.find(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		p := DefaultProfile(userID)
+		p.ID = primitive.NewObjectID()
+		p.FeedTokenHash = hash
+		_, err := s.Repo.Insert(ctx, p)
+		return err
+	}
+	profile.FeedTokenHash = hash
+	return s.Repo.UpdateByID(ctx, profile.ID.Hex(), *profile)
+}
+
+// RotateFeedToken issues a new calendar feed token for the caller, revoking
+// the previous one. Only its hash is stored, the token is shown once.
+func (s *MongoUserService) RotateFeedToken(ctx context.Context, userID string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.RotateFeedToken")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return "", err
+	}
+	if user.UserID != userID {
+		return "", ErrForbidden // even admins, the token reads the user's own feed
+	}
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.setFeedToken(ctx, userID, hashToken(token)); err != nil {
+		return "", err
+	}
+	slog.InfoContext(ctx, "feed token rotated", "userId", userID)
+	return token, nil
+}
+
+// RevokeFeedToken stops the feed URL of userID from working. Users revoke
+// their own, admins anybody's.
+func (s *MongoUserService) RevokeFeedToken(ctx context.Context, userID string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.RevokeFeedToken")
+	defer func() { tracing.End(span, err) }()
+	user, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+	if user.UserID != userID && !user.HasRole(auth.RoleAdmin) {
+		return ErrForbidden
+	}
+	if err := s.setFeedToken(ctx, userID, ""); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "feed token revoked", "userId", userID)
+	return nil
+}
+
+// OpenFeed resolves a feed token to its user. The returned context acts as
+// that user in their organization, the same rights they have on the API.
+func (s *MongoUserService) OpenFeed(ctx context.Context, feedToken string) (_ context.Context, _ string, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.OpenFeed")
+	defer func() { tracing.End(span, err) }()
+	if feedToken == "" {
+		return nil, "", ErrInvalidFeedToken
+	}
+	users, err := s.Feeds.FindAll(ctx, query.Eq("feedTokenHash", hashToken(feedToken)))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(users) == 0 {
+		return nil, "", ErrInvalidFeedToken
+	}
+	u := users[0]
+	ctx = tenant.WithOrg(ctx, u.OrgID)
+	return auth.WithIdentity(ctx, auth.Identity{UserID: u.UserID, OrgID: u.OrgID, Method: "feed"}), u.UserID, nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chetanugale/scheduling-system/auth"
+	"github.com/chetanugale/scheduling-system/mocker"
+	"github.com/chetanugale/scheduling-system/models"
+	"github.com/chetanugale/scheduling-system/query"
+	"github.com/chetanugale/scheduling-system/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOutsideWorkingHours(t *testing.T) {
+	// 14:00-15:00 UTC is 16:00 in Berlin, 11:00 in Sao Paulo and 23:00 in Tokyo
+	afternoon := models.TimeSlot{ID: primitive.NewObjectID(), StartTime: at(14), EndTime: at(15)}
+	// 07:30-08:30 UTC is 09:30 in Berlin but 16:30-17:30 in Tokyo
+	morning := models.TimeSlot{ID: primitive.NewObjectID(), StartTime: at(7).Add(30 * time.Minute), EndTime: at(8).Add(30 * time.Minute)}
+	profiles := []models.User{
+		{UserID: "berlin", TimeZone: "Europe/Berlin", WorkStart: "09:00", WorkEnd: "17:00"},
+		{UserID: "saopaulo", TimeZone: "America/Sao_Paulo", WorkStart: "09:00", WorkEnd: "17:00"},
+		{UserID: "tokyo", TimeZone: "Asia/Tokyo", WorkStart: "08:00", WorkEnd: "17:00"},
+		{UserID: "unknown", WorkStart: "09:00", WorkEnd: "17:00"}, // no zone, not judged
+	}
+
+	outside := OutsideWorkingHours([]models.TimeSlot{afternoon, morning}, profiles)
+	assert.Equal(t, map[string][]string{
+		afternoon.ID.Hex(): {"tokyo"},
+		morning.ID.Hex():   {"saopaulo", "tokyo"},
+	}, outside)
+
+	// the working day follows the local clock across a DST change, 29 March 2026 in Berlin
+	sunday := models.TimeSlot{ID: primitive.NewObjectID(), StartTime: time.Date(2026, 3, 29, 7, 0, 0, 0, time.UTC), EndTime: time.Date(2026, 3, 29, 8, 0, 0, 0, time.UTC)}
+	assert.Empty(t, OutsideWorkingHours([]models.TimeSlot{sunday}, profiles[:1]))
+}
+
+func TestUpdateProfile(t *testing.T) {
+	repo := new(mocker.MockRepo[models.User])
+	svc := &MongoUserService{Repo: repo}
+
+	_, err := svc.UpdateProfile(ctxAs("alice"), "alice", models.User{TimeZone: "Mars/Olympus_Mons"})
+	assert.ErrorIs(t, err, ErrInvalidTimeZone)
+	_, err = svc.UpdateProfile(ctxAs("alice"), "alice", models.User{TimeZone: "Local"})
+	assert.ErrorIs(t, err, ErrInvalidTimeZone)
+	_, err = svc.UpdateProfile(ctxAs("alice"), "alice", models.User{WorkStart: "18:00", WorkEnd: "09:00"})
+	assert.ErrorIs(t, err, ErrInvalidWorkHours)
+	_, err = svc.UpdateProfile(ctxAs("bob"), "alice", models.User{})
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	repo.On("FindAll", mock.Anything, query.Eq("userId", "alice")).Return([]models.User{}, nil).Once()
+	repo.On("Insert", mock.Anything, mock.MatchedBy(func(u models.User) bool {
+		return u.UserID == "alice" && u.TimeZone == "Asia/Kolkata" && u.WorkStart == DefaultWorkStart && !u.ID.IsZero()
+	})).Return(&models.User{UserID: "alice", TimeZone: "Asia/Kolkata"}, nil)
+	_, err = svc.UpdateProfile(ctxAs("alice"), "alice", models.User{TimeZone: "Asia/Kolkata"})
+	assert.NoError(t, err)
+
+	existing := models.User{ID: primitive.NewObjectID(), UserID: "alice", TimeZone: "Asia/Kolkata"}
+	repo.On("FindAll", mock.Anything, query.Eq("userId", "alice")).Return([]models.User{existing}, nil)
+	repo.On("UpdateByID", mock.Anything, existing.ID.Hex(), mock.MatchedBy(func(u models.User) bool {
+		return u.ID == existing.ID && u.TimeZone == "Australia/Sydney" && u.WorkEnd == "18:00"
+	})).Return(nil)
+	_, err = svc.UpdateProfile(ctxAs("root", auth.RoleAdmin), "alice", models.User{TimeZone: "Australia/Sydney", WorkStart: "10:00", WorkEnd: "18:00"})
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestGetProfileDefaults(t *testing.T) {
+	repo := new(mocker.MockRepo[models.User])
+	svc := &MongoUserService{Repo: repo}
+	repo.On("FindAll", mock.Anything, query.Eq("userId", "carol")).Return([]models.User{}, nil)
+
+	profile, err := svc.GetProfile(ctxAs("alice"), "carol")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultProfile("carol"), *profile)
+}
+
+func TestFeedToken(t *testing.T) {
+	repo := new(mocker.MockRepo[models.User])
+	feeds := new(mocker.MockRepo[models.User])
+	svc := &MongoUserService{Repo: repo, Feeds: feeds}
+
+	_, err := svc.RotateFeedToken(ctxAs("root", auth.RoleAdmin), "alice")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	existing := models.User{ID: primitive.NewObjectID(), UserID: "alice", OrgID: "eng", TimeZone: "Asia/Kolkata"}
+	var stored string
+	repo.On("FindAll", mock.Anything, query.Eq("userId", "alice")).Return([]models.User{existing}, nil)
+	repo.On("UpdateByID", mock.Anything, existing.ID.Hex(), mock.MatchedBy(func(u models.User) bool {
+		stored = u.FeedTokenHash
+		return u.TimeZone == "Asia/Kolkata"
+	})).Return(nil)
+	token, err := svc.RotateFeedToken(ctxAs("alice"), "alice")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, hashToken(token), stored, "only the hash is stored")
+
+	feeds.On("FindAll", mock.Anything, query.Eq("feedTokenHash", hashToken(token))).Return([]models.User{existing}, nil)
+	feeds.On("FindAll", mock.Anything, mock.Anything).Return([]models.User{}, nil)
+	ctx, userID, err := svc.OpenFeed(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", userID)
+	id, _ := auth.FromContext(ctx)
+	assert.Equal(t, "alice", id.UserID)
+	org, _ := tenant.FromContext(ctx)
+	assert.Equal(t, "eng", org)
+	_, _, err = svc.OpenFeed(context.Background(), "guess")
+	assert.ErrorIs(t, err, ErrInvalidFeedToken)
+
+	assert.NoError(t, svc.RevokeFeedToken(ctxAs("root", auth.RoleAdmin), "alice"))
+	assert.Empty(t, stored)
+}
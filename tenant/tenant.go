@@ -0,0 +1,41 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrgKey is the gin context key holding the organization the request is scoped to.
+const OrgKey = "orgId"
+
+// Header lets admins that are not bound to an organization pick one per request.
+const Header = "X-Org-ID"
+
+var ErrNoOrg = errors.New("organization required")
+
+type orgCtxKey struct{}
+
+func Set(c *gin.Context, orgID string) {
+	c.Set(OrgKey, orgID)
+}
+
+// WithOrg scopes ctx to orgID for code paths that do not go through the auth
+// middleware, such as public poll links.
+func WithOrg(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, orgCtxKey{}, orgID)
+}
+
+// FromContext returns the organization stored on ctx, usually the *gin.Context
+// passed down from a handler.
+func FromContext(ctx context.Context) (string, error) {
+	org, ok := ctx.Value(orgCtxKey{}).(string)
+	if !ok {
+		org, _ = ctx.Value(OrgKey).(string)
+	}
+	if org == "" {
+		return "", ErrNoOrg
+	}
+	return org, nil
+}
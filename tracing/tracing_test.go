@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	_, err := Setup(context.Background(), ExporterNone, "")
+	assert.NoError(t, err)
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := record(t)
+
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(Middleware())
+	router.GET("/events/:id", func(c *gin.Context) {
+		_, span := Start(c, "EventService.GetEvent")
+		End(span, errors.New("boom"))
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/events/abc", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Ended()
+	assert.Len(t, spans, 2)
+	child, server := spans[0], spans[1]
+	assert.Equal(t, "GET /events/:id", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String(), "continues the caller's trace")
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, server.Status().Code)
+	assert.Contains(t, server.Attributes(), attribute.String("url.path", "/events/abc"))
+
+	assert.Equal(t, "EventService.GetEvent", child.Name())
+	assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+	assert.Equal(t, codes.Error, child.Status().Code)
+}
+
+func TestMiddlewareMasksTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := record(t)
+
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/polls/:token", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req, _ := http.NewRequest(http.MethodGet, "/polls/s3cret", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Ended()
+	assert.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("url.path", "/polls/:token"))
+}
+
+func TestSetup(t *testing.T) {
+	_, err := Setup(context.Background(), "jaeger", "")
+	assert.Error(t, err)
+
+	shutdown, err := Setup(context.Background(), ExporterOTLP, "http://localhost:4318")
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}